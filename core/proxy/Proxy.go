@@ -2,12 +2,21 @@ package proxy
 
 import (
 	"context"
+	"crypto/tls"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// 负载均衡模式
+const (
+	BalancePriority   = "priority"    // 总是选择优先级最高的可用后端
+	BalanceRoundRobin = "round-robin" // 每个新连接轮流选择可用后端
+	BalanceLeastConn  = "least-conn"  // 选择当前连接数最少的可用后端
+)
+
 type Proxy interface {
-	Start() error
+	Start(ctx context.Context) error
 	Close()
 }
 
@@ -17,6 +26,7 @@ type BackendConfig struct {
 	Host     string
 	Port     int
 	Priority int // 优先级 (数字越小优先级越高)
+	Weight   int // 同优先级后端间的流量权重，0表示只在同级有权重的后端都不可用时使用
 }
 
 // 后端数据库状态
@@ -27,13 +37,24 @@ type BackendStatus struct {
 	Context     context.Context
 	Cancel      context.CancelFunc
 	Mutex       sync.RWMutex
+	ActiveConns atomic.Int64 // 当前转发中的客户端连接数
 }
 
 type DefaultProxy struct {
-	ListenAddr  string
-	CurrentIdx  int
-	Mutex       sync.RWMutex
-	HealthCheck struct {
+	ListenAddr         string
+	CurrentIdx         int
+	Balance            string        // 负载均衡模式，为空时按优先级
+	Failback           bool          // 高优先级后端恢复时关闭低优先级后端上的空闲连接
+	FailbackIdle       time.Duration // 连接空闲多久后可被回切关闭
+	ShutdownTimeout    time.Duration // 停止时等待已有连接结束的最长时间
+	MaxConnsPerBackend int           // 每个后端的最大连接数，0表示不限制
+	DialTimeout        time.Duration // 连接后端的超时时间
+	IdleTimeout        time.Duration // 连接两个方向都没有数据超过该时间后关闭，0表示不限制
+	ListenTLS          *tls.Config   // 不为空时在监听端口上终止TLS
+	BackendTLS         bool          // 以TLS连接后端（转发与健康检查）
+	BackendTLSInsecure bool          // 不校验后端证书，用于自签名的开发证书
+	Mutex              sync.RWMutex
+	HealthCheck        struct {
 		Query      string
 		Expected   string
 		Timeout    time.Duration
@@ -41,3 +62,21 @@ type DefaultProxy struct {
 		CancelFunc context.CancelFunc
 	}
 }
+
+// 单个后端的状态报告，用于管理接口的JSON输出
+type BackendReport struct {
+	Name      string    `json:"name"`
+	Host      string    `json:"host"`
+	Port      int       `json:"port"`
+	Available bool      `json:"available"`
+	LastCheck time.Time `json:"lastCheck"`
+	LastError string    `json:"lastError,omitempty"`
+	Active    bool      `json:"active"`
+	Conns     int64     `json:"connections"`
+}
+
+// 代理状态报告，由管理接口 /status.json 返回
+type StatusReport struct {
+	ActiveIndex int             `json:"activeIndex"`
+	Backends    []BackendReport `json:"backends"`
+}
@@ -2,62 +2,120 @@ package db
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/tls"
 	"database/sql"
+	"encoding/hex"
 	"errors"
 	"fmt"
-	go_ora "github.com/sijms/go-ora/v2"
 	"github.com/yusiwen/myUtilities/core/proxy"
 	"io"
 	"log"
+	mathrand "math/rand/v2"
 	"net"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // TCP 代理服务器
-type OracleBackendConfig struct {
+type DBBackendConfig struct {
 	proxy.BackendConfig
-	Username    string
-	Password    string
-	ServiceName string
+	Username string
+	Password string
+	Database string // 数据库名，Oracle为服务名
 }
 
-type OracleBackendStatus struct {
+type DBBackendStatus struct {
 	proxy.BackendStatus
-	Config OracleBackendConfig
+	Config DBBackendConfig
+
+	conns map[*proxiedConn]struct{} // 正在转发的连接，由Mutex保护
+
+	// SQL健康检查复用的连接池，在StartHealthChecks中打开，StopHealthChecks中关闭
+	healthDB    *sql.DB
+	healthDBErr error
 }
 
-type OracleProxy struct {
+type DBProxy struct {
 	proxy.DefaultProxy
-	Backends []*OracleBackendStatus
+	Backends []*DBBackendStatus
+	Dialect  *Dialect // 数据库类型，决定SQL健康检查使用的驱动，为空时按Oracle处理
+
+	clients sync.WaitGroup // 正在处理的客户端连接
 }
 
-// 启动代理服务器
-func (p *OracleProxy) Start() error {
+func (p *DBProxy) dialect() *Dialect {
+	if p.Dialect == nil {
+		return OracleDialect
+	}
+	return p.Dialect
+}
+
+// 启动代理服务器，ctx取消后停止接受新连接，等待已有连接结束后返回
+func (p *DBProxy) Start(ctx context.Context) error {
 	// 启动健康检查
 	p.StartHealthChecks()
 
 	// 启动代理服务器
-	log.Printf("Starting Oracle proxy on %s", p.ListenAddr)
+	log.Printf("Starting %s proxy on %s", p.dialect().Name, p.ListenAddr)
 	listener, err := net.Listen("tcp", p.ListenAddr)
 	if err != nil {
 		return fmt.Errorf("failed to start listener: %w", err)
 	}
-	defer listener.Close()
+	if p.ListenTLS != nil {
+		listener = tls.NewListener(listener, p.ListenTLS)
+		log.Printf("Accepting TLS connections")
+	}
+	stop := context.AfterFunc(ctx, func() { listener.Close() })
+	defer stop()
 
 	for {
 		clientConn, err := listener.Accept()
 		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
 			log.Printf("Accept error: %v", err)
 			continue
 		}
-		log.Printf("New client connection from %s", clientConn.RemoteAddr())
+		p.clients.Add(1)
+		go func() {
+			defer p.clients.Done()
+			p.handleClient(ctx, clientConn)
+		}()
+	}
+
+	p.drain()
+	return nil
+}
 
-		go p.handleClient(clientConn)
+// drain 等待正在转发的连接结束，超过ShutdownTimeout后强制关闭剩余连接
+func (p *DBProxy) drain() {
+	done := make(chan struct{})
+	go func() {
+		p.clients.Wait()
+		close(done)
+	}()
+
+	log.Printf("Stopped accepting connections, waiting up to %s for active connections", p.ShutdownTimeout)
+	select {
+	case <-done:
+		log.Printf("All connections closed")
+		return
+	case <-time.After(p.ShutdownTimeout):
+	}
+
+	closed := 0
+	for _, backend := range p.Backends {
+		closed += backend.closeIdleConns(0)
 	}
+	log.Printf("Shutdown timeout reached, closed %d active connection(s)", closed)
+	<-done
 }
 
-func (p *OracleProxy) Close() {
+func (p *DBProxy) Close() {
 	// 停止健康检查
 	p.StopHealthChecks()
 
@@ -69,34 +127,47 @@ func (p *OracleProxy) Close() {
 		backend.IsAvailable = false
 		backend.Mutex.Unlock()
 	}
-	log.Println("Oracle proxy closed")
+	log.Printf("%s proxy closed", p.dialect().Name)
 }
 
 // 处理客户端连接
-func (p *OracleProxy) handleClient(clientConn net.Conn) {
+func (p *DBProxy) handleClient(ctx context.Context, clientConn net.Conn) {
 	defer clientConn.Close()
 
+	// 每个连接分配一个短ID，该连接的所有日志均带上此ID便于关联
+	connID := newConnID()
+	logf := func(format string, args ...interface{}) {
+		log.Printf("[conn %s] "+format, append([]interface{}{connID}, args...)...)
+	}
+	start := time.Now()
+	var bytesUp, bytesDown int64
+
+	logf("New client connection from %s", clientConn.RemoteAddr())
+
 	for {
 		var rst = func() bool {
-			log.Printf("Routing connection for %s", clientConn.RemoteAddr())
+			logf("Routing connection for %s", clientConn.RemoteAddr())
 			// 获取活动后端
-			backend, err := p.getActiveBackend()
+			backend, err := p.getActiveBackend(connID)
 			if err != nil {
-				log.Printf("Failed to route: %v", err)
-				return false
+				logf("Failed to route: %v", err)
+				// 所有可用后端都已满时直接拒绝，不再重试
+				return errors.Is(err, errBackendsFull)
 			}
+			defer backend.ActiveConns.Add(-1)
 
-			log.Printf("Routing connection to %s (%s)", backend.Config.Name, backend.Config.Host)
+			logf("Routing connection to %s (%s)", backend.Config.Name, backend.Config.Host)
 
 			// 连接到后端数据库
-			backendConn, err := net.DialTimeout("tcp",
-				fmt.Sprintf("%s:%d", backend.Config.Host, backend.Config.Port), 3*time.Second)
+			backendConn, err := p.dialBackend(backend, p.dialTimeout())
 			if err != nil {
-				log.Printf("Failed to connect to backend %s: %v", backend.Config.Name, err)
+				logf("Failed to connect to backend %s: %v", backend.Config.Name, err)
 				return false
 			}
 			var once sync.Once
 			defer once.Do(func() { backendConn.Close() })
+			tracked := newProxiedConn(clientConn, backendConn)
+			defer backend.trackConn(tracked)()
 
 			// 启动双向数据转发
 			var wg sync.WaitGroup
@@ -105,27 +176,37 @@ func (p *OracleProxy) handleClient(clientConn net.Conn) {
 			// 客户端 -> 后端
 			go func() {
 				defer wg.Done()
-				_, err := io.Copy(backendConn, clientConn)
-				if err != nil && !errors.Is(err, io.EOF) {
-					log.Printf("Client->Backend copy error: %v, %s", err, clientConn.RemoteAddr())
+				n, err := io.Copy(activityWriter{backendConn, tracked}, p.source(clientConn, tracked))
+				atomic.AddInt64(&bytesUp, n)
+				if errors.Is(err, errIdleTimeout) {
+					logf("Closing connection from %s: idle for %s", clientConn.RemoteAddr(), p.IdleTimeout)
+					tracked.close()
+				} else if err != nil && !errors.Is(err, io.EOF) {
+					logf("Client->Backend copy error: %v, %s", err, clientConn.RemoteAddr())
 				}
-				log.Printf("Exit Client->Backend forwarding for %s", clientConn.RemoteAddr())
+				// 客户端断开后关闭后端连接，结束另一个方向的转发
+				once.Do(func() { backendConn.Close() })
+				logf("Exit Client->Backend forwarding for %s", clientConn.RemoteAddr())
 			}()
 
 			// 后端 -> 客户端
 			go func() {
 				defer wg.Done()
-				_, err := io.Copy(clientConn, backendConn)
-				if err != nil && !errors.Is(err, io.EOF) {
-					log.Printf("Backend->Client copy error: %v, %s", err, clientConn.RemoteAddr())
+				n, err := io.Copy(activityWriter{clientConn, tracked}, p.source(backendConn, tracked))
+				atomic.AddInt64(&bytesDown, n)
+				if errors.Is(err, errIdleTimeout) {
+					logf("Closing connection from %s: idle for %s", clientConn.RemoteAddr(), p.IdleTimeout)
+					tracked.close()
+				} else if err != nil && !errors.Is(err, io.EOF) {
+					logf("Backend->Client copy error: %v, %s", err, clientConn.RemoteAddr())
 				}
-				log.Printf("Exit Backend->Client forwarding for %s", clientConn.RemoteAddr())
+				logf("Exit Backend->Client forwarding for %s", clientConn.RemoteAddr())
 			}()
 
 			go func() {
 				<-backend.Context.Done()
 				once.Do(func() { backendConn.Close() })
-				log.Printf("Helper goroutine for %s exited", clientConn.RemoteAddr())
+				logf("Helper goroutine for %s exited", clientConn.RemoteAddr())
 			}()
 
 			wg.Wait()
@@ -143,39 +224,170 @@ func (p *OracleProxy) handleClient(clientConn net.Conn) {
 		if rst {
 			break
 		}
-		log.Printf("Backend is not available, retrying...")
+		if ctx.Err() != nil {
+			logf("Proxy is shutting down, giving up on %s", clientConn.RemoteAddr())
+			break
+		}
+		logf("Backend is not available, retrying...")
 	}
-	log.Printf("Goroutine for %s exited", clientConn.RemoteAddr())
+	logf("Connection from %s closed: %d bytes client->backend, %d bytes backend->client, duration %s",
+		clientConn.RemoteAddr(), atomic.LoadInt64(&bytesUp), atomic.LoadInt64(&bytesDown),
+		time.Since(start).Round(time.Millisecond))
 }
 
-// 获取活动后端
-func (p *OracleProxy) getActiveBackend() (*OracleBackendStatus, error) {
+// newConnID 生成8位十六进制的连接ID
+func newConnID() string {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%08x", time.Now().UnixNano()&0xffffffff)
+	}
+	return hex.EncodeToString(b)
+}
+
+// dialBackend 连接后端，开启BackendTLS时建立TLS连接并完成握手
+func (p *DBProxy) dialBackend(backend *DBBackendStatus, timeout time.Duration) (net.Conn, error) {
+	addr := net.JoinHostPort(backend.Config.Host, strconv.Itoa(backend.Config.Port))
+	if !p.BackendTLS {
+		return net.DialTimeout("tcp", addr, timeout)
+	}
+	return tls.DialWithDialer(&net.Dialer{Timeout: timeout}, "tcp", addr, &tls.Config{
+		ServerName:         backend.Config.Host,
+		InsecureSkipVerify: p.BackendTLSInsecure,
+	})
+}
+
+// dialTimeout 连接后端的超时时间，未设置时为3秒
+func (p *DBProxy) dialTimeout() time.Duration {
+	if p.DialTimeout <= 0 {
+		return 3 * time.Second
+	}
+	return p.DialTimeout
+}
+
+// errBackendsFull 所有可用后端的连接数都已达到MaxConnsPerBackend
+var errBackendsFull = errors.New("all available backends are at their connection limit")
+
+// 获取活动后端，选中后端的连接数已加1，调用方在连接结束后需减1
+func (p *DBProxy) getActiveBackend(connID string) (*DBBackendStatus, error) {
 	p.Mutex.Lock()
 	defer p.Mutex.Unlock()
 
-	// 查找第一个可用的后端（按优先级）
-	for i, backend := range p.Backends {
-		if backend.IsAvailable {
-			if backend.Context == nil || backend.Context.Err() != nil {
-				backend.Context, backend.Cancel = context.WithCancel(context.Background())
+	i := p.pickBackend()
+	if i < 0 {
+		for _, backend := range p.Backends {
+			if backend.available() {
+				return nil, errBackendsFull
 			}
+		}
+		return nil, errors.New("no available route found")
+	}
+	backend := p.Backends[i]
+	backend.Mutex.Lock()
+	backend.ActiveConns.Add(1)
+	backend.Mutex.Unlock()
+	if backend.Context == nil || backend.Context.Err() != nil {
+		backend.Context, backend.Cancel = context.WithCancel(context.Background())
+	}
+
+	// 更新当前选中的后端
+	p.CurrentIdx = i
+
+	mode := p.Balance
+	if mode == "" {
+		mode = proxy.BalancePriority
+	}
+	log.Printf("[conn %s] Using new route by %s: %s", connID, mode, backend.Config.Name)
+	return backend, nil
+}
 
-			// 更新当前选中的后端
-			p.CurrentIdx = i
+// available 读取健康状态；IsAvailable由健康检查在backend.Mutex下写入
+func (b *DBBackendStatus) available() bool {
+	b.Mutex.RLock()
+	defer b.Mutex.RUnlock()
+	return b.IsAvailable
+}
 
-			log.Printf("Using new route by priority: %s", backend.Config.Name)
-			return backend, nil
+// usable 后端健康且连接数未达到上限
+func (p *DBProxy) usable(backend *DBBackendStatus) bool {
+	if !backend.available() {
+		return false
+	}
+	if p.MaxConnsPerBackend <= 0 {
+		return true
+	}
+	return backend.ActiveConns.Load() < int64(p.MaxConnsPerBackend)
+}
+
+// pickBackend 按负载均衡模式选择可用且未满的后端，返回其下标，没有时返回-1；调用方需持有p.Mutex
+func (p *DBProxy) pickBackend() int {
+	switch p.Balance {
+	case proxy.BalanceRoundRobin:
+		// 从上次选中的后端的下一个开始轮询
+		for n := 1; n <= len(p.Backends); n++ {
+			i := (p.CurrentIdx + n) % len(p.Backends)
+			if p.usable(p.Backends[i]) {
+				return i
+			}
+		}
+	case proxy.BalanceLeastConn:
+		// 连接数相同时选择优先级较高（下标较小）的后端
+		best := -1
+		for i, backend := range p.Backends {
+			if p.usable(backend) && (best < 0 || backend.ActiveConns.Load() < p.Backends[best].ActiveConns.Load()) {
+				best = i
+			}
 		}
+		return best
+	default:
+		return p.pickByPriority()
+	}
+	return -1
+}
+
+// pickByPriority 在优先级最高的可用后端中按权重随机选择；该级没有权重大于0的可用后端时，
+// 选择其中第一个（权重为0的后端）。Backends已按优先级排序
+func (p *DBProxy) pickByPriority() int {
+	first, total := -1, 0
+	for i, backend := range p.Backends {
+		if !p.usable(backend) {
+			continue
+		}
+		if first < 0 {
+			first = i
+		} else if backend.Config.Priority != p.Backends[first].Config.Priority {
+			break
+		}
+		total += max(backend.Config.Weight, 0)
+	}
+	if first < 0 || total == 0 {
+		return first
 	}
 
-	return nil, errors.New("no available route found")
+	n := mathrand.IntN(total)
+	for i := first; i < len(p.Backends); i++ {
+		backend := p.Backends[i]
+		if backend.Config.Priority != p.Backends[first].Config.Priority {
+			break
+		}
+		if backend.Config.Weight <= 0 || !p.usable(backend) {
+			continue
+		}
+		if n -= backend.Config.Weight; n < 0 {
+			return i
+		}
+	}
+	return first
 }
 
 // 启动健康检查
-func (p *OracleProxy) StartHealthChecks() {
+func (p *DBProxy) StartHealthChecks() {
 	ctx, cancel := context.WithCancel(context.Background())
 	p.HealthCheck.CancelFunc = cancel
 
+	for _, backend := range p.Backends {
+		p.openHealthDB(backend)
+	}
+
 	// 对所有后端启动独立健康检查
 	for _, backend := range p.Backends {
 		go p.runHealthCheck(ctx, backend)
@@ -183,14 +395,36 @@ func (p *OracleProxy) StartHealthChecks() {
 }
 
 // 停止健康检查
-func (p *OracleProxy) StopHealthChecks() {
+func (p *DBProxy) StopHealthChecks() {
 	if p.HealthCheck.CancelFunc != nil {
 		p.HealthCheck.CancelFunc()
 	}
+	for _, backend := range p.Backends {
+		if backend.healthDB != nil {
+			backend.healthDB.Close()
+		}
+	}
+}
+
+// healthConnMaxLifetime 健康检查连接的最长使用时间，到期后重新建立，避免长期持有同一会话
+const healthConnMaxLifetime = 5 * time.Minute
+
+// openHealthDB 为后端打开健康检查用的连接池，只保留一个连接在各次检查间复用
+func (p *DBProxy) openHealthDB(backend *DBBackendStatus) {
+	d := p.dialect()
+	db, err := sql.Open(d.DriverName, d.DSN(backend.Config, p.BackendTLS, p.BackendTLSInsecure))
+	if err != nil {
+		backend.healthDBErr = fmt.Errorf("failed to open connection: %w", err)
+		return
+	}
+	db.SetMaxOpenConns(1)
+	db.SetMaxIdleConns(1)
+	db.SetConnMaxLifetime(healthConnMaxLifetime)
+	backend.healthDB = db
 }
 
 // 运行健康检查循环
-func (p *OracleProxy) runHealthCheck(ctx context.Context, backend *OracleBackendStatus) {
+func (p *DBProxy) runHealthCheck(ctx context.Context, backend *DBBackendStatus) {
 	ticker := time.NewTicker(p.HealthCheck.Interval)
 	defer ticker.Stop()
 
@@ -209,7 +443,7 @@ func (p *OracleProxy) runHealthCheck(ctx context.Context, backend *OracleBackend
 }
 
 // 执行健康检查
-func (p *OracleProxy) performHealthCheck(backend *OracleBackendStatus) {
+func (p *DBProxy) performHealthCheck(backend *DBBackendStatus) {
 	// 1. TCP 连接检查
 	if err := p.checkTCPConnection(backend); err != nil {
 		backend.Mutex.Lock()
@@ -240,6 +474,7 @@ func (p *OracleProxy) performHealthCheck(backend *OracleBackendStatus) {
 
 	// 标记为健康
 	backend.Mutex.Lock()
+	recovered := !backend.IsAvailable && !backend.LastCheck.IsZero()
 	backend.IsAvailable = true
 	backend.LastError = nil
 	backend.LastCheck = time.Now()
@@ -249,12 +484,14 @@ func (p *OracleProxy) performHealthCheck(backend *OracleBackendStatus) {
 	backend.Mutex.Unlock()
 
 	log.Printf("Backend %s is healthy", backend.Config.Name)
+	if recovered {
+		p.failback(backend)
+	}
 }
 
 // 检查 TCP 连接
-func (p *OracleProxy) checkTCPConnection(backend *OracleBackendStatus) error {
-	conn, err := net.DialTimeout("tcp",
-		fmt.Sprintf("%s:%d", backend.Config.Host, backend.Config.Port), 3*time.Second)
+func (p *DBProxy) checkTCPConnection(backend *DBBackendStatus) error {
+	conn, err := p.dialBackend(backend, 3*time.Second)
 	if err != nil {
 		return fmt.Errorf("TCP connection failed: %w", err)
 	}
@@ -263,23 +500,18 @@ func (p *OracleProxy) checkTCPConnection(backend *OracleBackendStatus) error {
 }
 
 // 检查 SQL 健康
-func (p *OracleProxy) checkSQLHealth(backend *OracleBackendStatus) error {
+func (p *DBProxy) checkSQLHealth(backend *DBBackendStatus) error {
+	if backend.healthDBErr != nil {
+		return backend.healthDBErr
+	}
+
 	// 创建带超时的上下文
 	ctx, cancel := context.WithTimeout(context.Background(), p.HealthCheck.Timeout)
 	defer cancel()
 
-	// 连接到数据库
-	connStr := go_ora.BuildUrl(backend.Config.Host, backend.Config.Port, backend.Config.ServiceName,
-		backend.Config.Username, backend.Config.Password, nil)
-	db, err := sql.Open("oracle", connStr)
-	if err != nil {
-		return fmt.Errorf("failed to open connection: %w", err)
-	}
-	defer db.Close()
-
 	// 执行健康检查查询
 	var result string
-	err = db.QueryRowContext(ctx, p.HealthCheck.Query).Scan(&result)
+	err := backend.healthDB.QueryRowContext(ctx, p.HealthCheck.Query).Scan(&result)
 	if err != nil {
 		return fmt.Errorf("query execution failed: %w", err)
 	}
@@ -292,8 +524,34 @@ func (p *OracleProxy) checkSQLHealth(backend *OracleBackendStatus) error {
 	return nil
 }
 
+// StatusReport 返回结构化的后端状态，供管理接口 /status.json 使用
+func (p *DBProxy) StatusReport() proxy.StatusReport {
+	p.Mutex.RLock()
+	defer p.Mutex.RUnlock()
+
+	report := proxy.StatusReport{ActiveIndex: p.CurrentIdx, Backends: make([]proxy.BackendReport, 0, len(p.Backends))}
+	for i, backend := range p.Backends {
+		backend.Mutex.RLock()
+		r := proxy.BackendReport{
+			Name:      backend.Config.Name,
+			Host:      backend.Config.Host,
+			Port:      backend.Config.Port,
+			Available: backend.IsAvailable,
+			LastCheck: backend.LastCheck,
+			Active:    i == p.CurrentIdx,
+			Conns:     backend.ActiveConns.Load(),
+		}
+		if backend.LastError != nil {
+			r.LastError = backend.LastError.Error()
+		}
+		backend.Mutex.RUnlock()
+		report.Backends = append(report.Backends, r)
+	}
+	return report
+}
+
 // 获取后端状态报告
-func (p *OracleProxy) GetStatusReport() string {
+func (p *DBProxy) GetStatusReport() string {
 	p.Mutex.RLock()
 	defer p.Mutex.RUnlock()
 
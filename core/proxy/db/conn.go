@@ -0,0 +1,112 @@
+package db
+
+import (
+	"errors"
+	"io"
+	"net"
+	"sync/atomic"
+	"time"
+)
+
+// proxiedConn 正在转发的一对客户端/后端连接，记录最后一次收发数据的时间，回切时据此判断连接是否空闲
+type proxiedConn struct {
+	client     net.Conn
+	backend    net.Conn
+	lastActive atomic.Int64 // UnixNano
+}
+
+func newProxiedConn(client, backend net.Conn) *proxiedConn {
+	c := &proxiedConn{client: client, backend: backend}
+	c.touch()
+	return c
+}
+
+// close 同时关闭两端，使两个方向的转发都能结束
+func (c *proxiedConn) close() {
+	c.backend.Close()
+	c.client.Close()
+}
+
+func (c *proxiedConn) touch() {
+	c.lastActive.Store(time.Now().UnixNano())
+}
+
+func (c *proxiedConn) idleFor() time.Duration {
+	return time.Since(time.Unix(0, c.lastActive.Load()))
+}
+
+// activityWriter 写入数据时刷新连接的活动时间
+type activityWriter struct {
+	w    io.Writer
+	conn *proxiedConn
+}
+
+func (a activityWriter) Write(b []byte) (int, error) {
+	a.conn.touch()
+	return a.w.Write(b)
+}
+
+// trackConn 登记后端上的转发连接，返回的函数用于注销
+func (b *DBBackendStatus) trackConn(c *proxiedConn) func() {
+	b.Mutex.Lock()
+	if b.conns == nil {
+		b.conns = make(map[*proxiedConn]struct{})
+	}
+	b.conns[c] = struct{}{}
+	b.Mutex.Unlock()
+
+	return func() {
+		b.Mutex.Lock()
+		delete(b.conns, c)
+		b.Mutex.Unlock()
+	}
+}
+
+// closeIdleConns 关闭空闲时间不少于idle的转发连接，返回关闭的数量
+func (b *DBBackendStatus) closeIdleConns(idle time.Duration) int {
+	b.Mutex.RLock()
+	defer b.Mutex.RUnlock()
+
+	closed := 0
+	for c := range b.conns {
+		if c.idleFor() >= idle {
+			c.close()
+			closed++
+		}
+	}
+	return closed
+}
+
+// errIdleTimeout 连接两个方向都超过IdleTimeout没有数据
+var errIdleTimeout = errors.New("connection idle timeout")
+
+// idleReader 读取时设置截止时间，超时后若整条连接（两个方向）的空闲时间已达到timeout则返回errIdleTimeout，
+// 否则延长截止时间继续等待，避免单向长时间无数据（如等待慢查询结果）时被误判
+type idleReader struct {
+	conn    net.Conn
+	tracked *proxiedConn
+	timeout time.Duration
+}
+
+func (r idleReader) Read(b []byte) (int, error) {
+	for {
+		r.conn.SetReadDeadline(time.Unix(0, r.tracked.lastActive.Load()).Add(r.timeout))
+		n, err := r.conn.Read(b)
+		var ne net.Error
+		if n == 0 && errors.As(err, &ne) && ne.Timeout() {
+			if r.tracked.idleFor() < r.timeout {
+				continue
+			}
+			return 0, errIdleTimeout
+		}
+		return n, err
+	}
+}
+
+// source 返回转发时读取conn的Reader，设置了IdleTimeout时带空闲检测
+func (p *DBProxy) source(conn net.Conn, tracked *proxiedConn) io.Reader {
+	if p.IdleTimeout <= 0 {
+		return conn
+	}
+	return idleReader{conn: conn, tracked: tracked, timeout: p.IdleTimeout}
+}
@@ -0,0 +1,126 @@
+package db
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/yusiwen/myUtilities/core/proxy"
+)
+
+// testBackend 测试用后端的优先级、权重、健康状态和当前连接数
+type testBackend struct {
+	priority, weight int
+	down             bool
+	conns            int64
+}
+
+func newTestProxy(balance string, maxConns int, backends ...testBackend) *DBProxy {
+	p := &DBProxy{}
+	p.Balance = balance
+	p.MaxConnsPerBackend = maxConns
+	for i, b := range backends {
+		backend := &DBBackendStatus{}
+		backend.Config.Name = string(rune('a' + i))
+		backend.Config.Priority = b.priority
+		backend.Config.Weight = b.weight
+		backend.IsAvailable = !b.down
+		backend.ActiveConns.Store(b.conns)
+		p.Backends = append(p.Backends, backend)
+	}
+	return p
+}
+
+func TestPickBackend(t *testing.T) {
+	tests := []struct {
+		name     string
+		balance  string
+		maxConns int
+		current  int
+		backends []testBackend
+		want     int
+	}{
+		{"priority highest", "", 0, 0, []testBackend{{priority: 1}, {priority: 2}}, 0},
+		{"priority skips unavailable", proxy.BalancePriority, 0, 0, []testBackend{{priority: 1, down: true}, {priority: 2}}, 1},
+		{"priority skips full", proxy.BalancePriority, 2, 0, []testBackend{{priority: 1, conns: 2}, {priority: 2, conns: 5}, {priority: 3}}, 2},
+		{"priority weighted only", proxy.BalancePriority, 0, 0, []testBackend{{priority: 1}, {priority: 1, weight: 1}, {priority: 2, weight: 5}}, 1},
+		{"priority weight-0 fallback", proxy.BalancePriority, 0, 0, []testBackend{{priority: 1, weight: 1, down: true}, {priority: 1}, {priority: 2, weight: 1}}, 1},
+		{"priority weight-0 fallback when weighted full", proxy.BalancePriority, 1, 0, []testBackend{{priority: 1, weight: 3, conns: 1}, {priority: 1}}, 1},
+		{"priority none usable", proxy.BalancePriority, 1, 0, []testBackend{{priority: 1, down: true}, {priority: 2, conns: 1}}, -1},
+		{"round-robin next", proxy.BalanceRoundRobin, 0, 0, []testBackend{{}, {}, {}}, 1},
+		{"round-robin wraps", proxy.BalanceRoundRobin, 0, 2, []testBackend{{}, {}, {}}, 0},
+		{"round-robin skips unavailable and full", proxy.BalanceRoundRobin, 1, 0, []testBackend{{}, {down: true}, {conns: 1}}, 0},
+		{"round-robin none usable", proxy.BalanceRoundRobin, 0, 0, []testBackend{{down: true}, {down: true}}, -1},
+		{"least-conn fewest", proxy.BalanceLeastConn, 0, 0, []testBackend{{conns: 3}, {conns: 1}, {conns: 2}}, 1},
+		{"least-conn tie keeps order", proxy.BalanceLeastConn, 0, 0, []testBackend{{conns: 2}, {conns: 1}, {conns: 1}}, 1},
+		{"least-conn skips unavailable and full", proxy.BalanceLeastConn, 3, 0, []testBackend{{down: true}, {conns: 3}, {conns: 2}}, 2},
+		{"least-conn none usable", proxy.BalanceLeastConn, 1, 0, []testBackend{{conns: 1}, {down: true}}, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestProxy(tt.balance, tt.maxConns, tt.backends...)
+			p.CurrentIdx = tt.current
+			if got := p.pickBackend(); got != tt.want {
+				t.Errorf("pickBackend() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPickByPriorityWeights(t *testing.T) {
+	p := newTestProxy(proxy.BalancePriority, 0,
+		testBackend{priority: 1, weight: 3},
+		testBackend{priority: 1, weight: 1},
+		testBackend{priority: 1},
+		testBackend{priority: 2, weight: 10},
+	)
+	counts := make([]int, len(p.Backends))
+	for range 4000 {
+		counts[p.pickByPriority()]++
+	}
+	if counts[2] != 0 || counts[3] != 0 {
+		t.Fatalf("picked weight-0 or lower-priority backend: %v", counts)
+	}
+	// 权重3:1，允许较大的随机误差
+	if counts[0] < 2700 || counts[0] > 3300 {
+		t.Errorf("weighted split = %v, want about 3000:1000", counts)
+	}
+}
+
+func TestGetActiveBackendErrors(t *testing.T) {
+	p := newTestProxy(proxy.BalancePriority, 1, testBackend{conns: 1}, testBackend{down: true})
+	if _, err := p.getActiveBackend("test"); !errors.Is(err, errBackendsFull) {
+		t.Errorf("full backends: err = %v, want errBackendsFull", err)
+	}
+
+	p = newTestProxy(proxy.BalancePriority, 0, testBackend{down: true})
+	if _, err := p.getActiveBackend("test"); err == nil || errors.Is(err, errBackendsFull) {
+		t.Errorf("unavailable backends: err = %v, want no available route", err)
+	}
+
+	p = newTestProxy(proxy.BalancePriority, 2, testBackend{conns: 1})
+	backend, err := p.getActiveBackend("test")
+	if err != nil || backend != p.Backends[0] || backend.ActiveConns.Load() != 2 {
+		t.Errorf("getActiveBackend() = %v, %v; want first backend with 2 conns", backend, err)
+	}
+}
+
+// 健康检查在backend.Mutex下更新IsAvailable，选择后端时并发读取不能产生数据竞争（需-race）
+func TestPickBackendConcurrentHealthUpdate(t *testing.T) {
+	p := newTestProxy(proxy.BalanceLeastConn, 0, testBackend{}, testBackend{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := range 1000 {
+			backend := p.Backends[i%2]
+			backend.Mutex.Lock()
+			backend.IsAvailable = i%3 != 0
+			backend.Mutex.Unlock()
+		}
+	}()
+	for range 1000 {
+		p.Mutex.Lock()
+		p.pickBackend()
+		p.Mutex.Unlock()
+	}
+	<-done
+}
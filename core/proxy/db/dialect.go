@@ -0,0 +1,95 @@
+package db
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+
+	"github.com/go-sql-driver/mysql"
+	_ "github.com/jackc/pgx/v5/stdlib" // 注册 pgx 驱动
+	go_ora "github.com/sijms/go-ora/v2"
+)
+
+// Dialect 描述一种数据库的SQL健康检查方式，TCP转发与数据库类型无关
+type Dialect struct {
+	Name            string // 模式名，对应 --mode
+	DriverName      string // database/sql 驱动名
+	DefaultPort     int
+	DefaultQuery    string // 默认健康检查语句
+	DefaultExpected string // 默认健康检查期望结果
+	DSN             func(cfg DBBackendConfig, useTLS, insecure bool) string
+}
+
+var OracleDialect = &Dialect{
+	Name:            "oracle",
+	DriverName:      "oracle",
+	DefaultPort:     1521,
+	DefaultQuery:    "SELECT '1' FROM DUAL",
+	DefaultExpected: "1",
+	DSN: func(cfg DBBackendConfig, useTLS, insecure bool) string {
+		var options map[string]string
+		if useTLS {
+			options = map[string]string{"SSL": "true", "SSL VERIFY": strconv.FormatBool(!insecure)}
+		}
+		return go_ora.BuildUrl(cfg.Host, cfg.Port, cfg.Database, cfg.Username, cfg.Password, options)
+	},
+}
+
+var MySQLDialect = &Dialect{
+	Name:            "mysql",
+	DriverName:      "mysql",
+	DefaultPort:     3306,
+	DefaultQuery:    "SELECT 1",
+	DefaultExpected: "1",
+	DSN: func(cfg DBBackendConfig, useTLS, insecure bool) string {
+		c := mysql.NewConfig()
+		c.User = cfg.Username
+		c.Passwd = cfg.Password
+		c.Net = "tcp"
+		c.Addr = net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port))
+		c.DBName = cfg.Database
+		switch {
+		case useTLS && insecure:
+			c.TLSConfig = "skip-verify"
+		case useTLS:
+			c.TLSConfig = "true"
+		}
+		return c.FormatDSN()
+	},
+}
+
+var PostgresDialect = &Dialect{
+	Name:            "postgres",
+	DriverName:      "pgx",
+	DefaultPort:     5432,
+	DefaultQuery:    "SELECT 1",
+	DefaultExpected: "1",
+	DSN: func(cfg DBBackendConfig, useTLS, insecure bool) string {
+		sslmode := "disable"
+		switch {
+		case useTLS && insecure:
+			sslmode = "require"
+		case useTLS:
+			sslmode = "verify-full"
+		}
+		u := url.URL{
+			Scheme:   "postgres",
+			User:     url.UserPassword(cfg.Username, cfg.Password),
+			Host:     net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+			Path:     "/" + cfg.Database,
+			RawQuery: "sslmode=" + sslmode,
+		}
+		return u.String()
+	},
+}
+
+// DialectFor 根据 --mode 返回对应的数据库类型
+func DialectFor(mode string) (*Dialect, error) {
+	for _, d := range []*Dialect{OracleDialect, MySQLDialect, PostgresDialect} {
+		if d.Name == mode {
+			return d, nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported database mode: %s", mode)
+}
@@ -0,0 +1,44 @@
+package db
+
+import (
+	"log"
+
+	"github.com/yusiwen/myUtilities/core/proxy"
+)
+
+// failback 高优先级后端恢复健康时记录回切事件；开启回切且按优先级路由时，
+// 关闭优先级更低的后端上的空闲连接，客户端重连后会路由到恢复的后端
+func (p *DBProxy) failback(recovered *DBBackendStatus) {
+	p.Mutex.RLock()
+	defer p.Mutex.RUnlock()
+
+	idx := -1
+	for i, backend := range p.Backends {
+		if backend == recovered {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 || idx >= p.CurrentIdx {
+		return
+	}
+	active := p.Backends[p.CurrentIdx]
+	if active.Config.Priority <= recovered.Config.Priority {
+		return
+	}
+	log.Printf("Failback: backend '%s' recovered, it has higher priority than the active backend '%s'",
+		recovered.Config.Name, active.Config.Name)
+
+	if !p.Failback || (p.Balance != "" && p.Balance != proxy.BalancePriority) {
+		return
+	}
+	// 只回切优先级更低（数字更大）的后端，同优先级的后端继续分担流量
+	for _, backend := range p.Backends[idx+1:] {
+		if backend.Config.Priority <= recovered.Config.Priority {
+			continue
+		}
+		if n := backend.closeIdleConns(p.FailbackIdle); n > 0 {
+			log.Printf("Failback: closed %d idle connection(s) to backend '%s'", n, backend.Config.Name)
+		}
+	}
+}
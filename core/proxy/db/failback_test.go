@@ -0,0 +1,48 @@
+package db
+
+import (
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+// trackIdleConn 在后端上登记一条已空闲的转发连接，返回客户端一侧用于判断是否被关闭
+func trackIdleConn(t *testing.T, backend *DBBackendStatus) net.Conn {
+	t.Helper()
+	client, proxySide := net.Pipe()
+	backendSide, server := net.Pipe()
+	t.Cleanup(func() {
+		client.Close()
+		server.Close()
+	})
+	backend.trackConn(newProxiedConn(proxySide, backendSide))
+	return client
+}
+
+// closed 连接的另一端是否已关闭；未关闭时写入会等到超时
+func closed(c net.Conn) bool {
+	c.SetWriteDeadline(time.Now().Add(50 * time.Millisecond))
+	_, err := c.Write([]byte{0})
+	return errors.Is(err, io.ErrClosedPipe)
+}
+
+func TestFailbackClosesOnlyLowerPriority(t *testing.T) {
+	p := newTestProxy("", 0, testBackend{priority: 1}, testBackend{priority: 1}, testBackend{priority: 2})
+	p.Failback = true
+	p.CurrentIdx = 2
+	conns := make([]net.Conn, len(p.Backends))
+	for i, backend := range p.Backends {
+		conns[i] = trackIdleConn(t, backend)
+	}
+
+	p.failback(p.Backends[0])
+
+	// 同优先级的后端保留连接，只关闭优先级更低的后端上的空闲连接
+	for i, want := range []bool{false, false, true} {
+		if got := closed(conns[i]); got != want {
+			t.Errorf("backend %d: closed = %v, want %v", i, got, want)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package runner
 
 import (
 	"bufio"
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -9,6 +10,7 @@ import (
 	"os"
 	"os/exec"
 	"runtime"
+	"strings"
 	"sync"
 	"time"
 
@@ -16,14 +18,46 @@ import (
 )
 
 type Command struct {
-	Name    string `help:"Description of this command" default:""`
-	CmdLine string `help:"Command line" default:""`
+	Name    string        `help:"Description of this command" default:""`
+	CmdLine string        `help:"Command line" default:""`
+	Timeout time.Duration `help:"Maximum run time of this command, 0 uses the runner's timeout"`
+	Dir     string        `help:"Working directory of this command, empty for the current directory"`
+	Env     []string      `help:"Extra environment variables (KEY=VALUE) added to the inherited environment"`
+	Shell   string        `help:"Shell running this command, empty uses the runner's shell"`
+	NoShell bool          `help:"Run the program directly instead of through a shell"`
 }
 
 type CmdStatus struct {
+	name      string
 	isSuccess bool
 	exitCode  int
 	errMsg    string
+	canceled  bool          // 其他命令失败后被取消
+	timedOut  bool          // 超过timeout被结束
+	timeout   time.Duration // 命令的超时时间
+	duration  time.Duration
+}
+
+// err 返回命令失败的原因
+func (s *CmdStatus) err() error {
+	switch {
+	case s.timedOut:
+		return fmt.Errorf("[%s] timed out after %s", s.name, s.timeout)
+	case s.errMsg == "":
+		return fmt.Errorf("[%s] exit status %d", s.name, s.exitCode)
+	}
+	return errors.New(s.errMsg)
+}
+
+// waitDelay 命令退出或被结束后，等待其子进程释放输出管道的最长时间
+var waitDelay = 2 * time.Second
+
+// event 命令输出的一行或执行结束，由display统一输出到终端
+type event struct {
+	idx     int
+	started bool
+	line    string
+	status  *CmdStatus // 不为空表示命令已结束
 }
 
 var outputColor aec.ANSI
@@ -54,216 +88,364 @@ func (r *CommandRunner) Run() error {
 		return nil
 	}
 
-	r.wg.Add(3)
+	r.wg.Add(2)
 	go r.runCommands()
-	go r.d.refreshBuffer()
 	go r.d.update()
 
 	r.wg.Wait()
+	if len(r.Commands) > 1 {
+		r.printSummary()
+	}
 	return r.err
 }
 
+// parallel 同时执行的命令数
+func (r *CommandRunner) parallel() int {
+	if r.Parallel < 1 {
+		return 1
+	}
+	return min(r.Parallel, len(r.Commands))
+}
+
+// runCommands 以工作池执行命令，默认第一个命令失败后取消其余命令，KeepGoing时执行全部命令
 func (r *CommandRunner) runCommands() {
 	defer r.wg.Done()
-	defer close(r.output)
-	defer close(r.done)
-
-	for _, cmd := range r.Commands {
-		out := fmt.Sprintf("Executing [%s]...", cmd.Name)
-		fmt.Println(aec.Apply(out, outputColor))
-
-		err := r.runCommand(cmd)
-		<-r.d.clear
+	defer close(r.events)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var mu sync.Mutex
+	var failed int
+	jobs := make(chan int)
+	var workers sync.WaitGroup
+	for i := 0; i < r.parallel(); i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for idx := range jobs {
+				status := r.runCommand(ctx, idx)
+				r.statuses[idx] = status
+				r.events <- event{idx: idx, status: status}
+				if status.isSuccess || status.canceled {
+					continue
+				}
+				mu.Lock()
+				failed++
+				if r.err == nil {
+					r.err = status.err()
+				}
+				mu.Unlock()
+				if !r.KeepGoing {
+					cancel()
+				}
+			}
+		}()
+	}
 
-		if err != nil {
-			r.err = err
-			fmt.Println(aec.Apply("Error:", errColor))
-			fmt.Printf("%v\n", err)
-			break
-		} else {
-			fmt.Printf(ANSI_MOVE_UP)
-			out = fmt.Sprintf("%s done", out)
-			fmt.Print(ANSI_CLEAR_LINE)
-			fmt.Println(aec.Apply(out, outputColor))
+feed:
+	for idx := range r.Commands {
+		select {
+		case jobs <- idx:
+		case <-ctx.Done():
+			break feed
 		}
 	}
+	close(jobs)
+	workers.Wait()
+
+	if r.KeepGoing && failed > 0 {
+		r.err = fmt.Errorf("%d of %d commands failed", failed, len(r.Commands))
+	}
 }
 
-func (r *CommandRunner) runCommand(command Command) error {
-	//time.Sleep(1 * time.Second)
+func (r *CommandRunner) runCommand(ctx context.Context, idx int) *CmdStatus {
+	command := r.Commands[idx]
+	status := &CmdStatus{name: command.Name, timeout: command.Timeout}
+	if status.timeout <= 0 {
+		status.timeout = r.Timeout
+	}
+	start := time.Now()
+	defer func() { status.duration = time.Since(start) }()
 
-	cmd := exec.Command("bash", "-c", command.CmdLine)
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		return err
+	r.events <- event{idx: idx, started: true}
+
+	cmdCtx := ctx
+	if status.timeout > 0 {
+		var cancel context.CancelFunc
+		cmdCtx, cancel = context.WithTimeout(ctx, status.timeout)
+		defer cancel()
 	}
-	stderr, err := cmd.StderrPipe()
+
+	cmd, err := r.newCmd(cmdCtx, command)
 	if err != nil {
-		return err
+		status.errMsg = err.Error()
+		return status
+	}
+	setProcessGroup(cmd)
+	if command.Dir != "" {
+		// 目录不存在时exec只会报告找不到程序，这里给出明确的原因
+		if _, err := os.Stat(command.Dir); err != nil {
+			status.errMsg = fmt.Sprintf("working directory: %v", err)
+			return status
+		}
+		cmd.Dir = command.Dir
 	}
+	if len(command.Env) > 0 {
+		// 继承当前进程的环境变量，同名变量以命令配置的为准
+		cmd.Env = append(os.Environ(), command.Env...)
+	}
+	// 输出经由exec的复制goroutine写入管道，shell留下的子进程仍占用输出时，
+	// WaitDelay到期后关闭管道，不会一直阻塞读取
+	stdout, stdoutWriter := io.Pipe()
+	var stderr strings.Builder
+	cmd.Stdout = stdoutWriter
+	cmd.Stderr = &stderr
+	cmd.WaitDelay = waitDelay
 	err = cmd.Start()
 	if err != nil {
-		return err
+		status.errMsg = err.Error()
+		return status
 	}
 
-	stderrCh := make(chan string, 1)
+	waitCh := make(chan error, 1)
 	go func() {
-		errMsgBytes, err := io.ReadAll(stderr)
-		if err != nil {
-			log.Printf("Failed to read stderr: %v", err)
-		}
-		stderrCh <- string(errMsgBytes)
+		err := cmd.Wait()
+		stdoutWriter.Close()
+		waitCh <- err
 	}()
 
 	scanner := bufio.NewScanner(stdout)
 	for scanner.Scan() {
-		r.output <- scanner.Text()
+		r.events <- event{idx: idx, line: scanner.Text()}
 	}
 
 	if err := scanner.Err(); err != nil {
 		log.Printf("Output reading error: %v", err)
 	}
+	// 读取出错后丢弃其余输出，避免复制goroutine阻塞
+	io.Copy(io.Discard, stdout)
 
-	errorMsg := <-stderrCh
+	err = <-waitCh
+	if errors.Is(err, exec.ErrWaitDelay) {
+		// 命令已正常退出，只是留下的子进程仍占用输出
+		err = nil
+	}
+	errorMsg := stderr.String()
 
-	if err := cmd.Wait(); err != nil {
+	if err != nil {
+		status.errMsg = errorMsg
+		switch {
+		case ctx.Err() != nil:
+			status.canceled = true
+			status.errMsg = "canceled"
+		case errors.Is(cmdCtx.Err(), context.DeadlineExceeded):
+			status.timedOut = true
+		}
 		if exitError, ok := err.(*exec.ExitError); ok {
-			r.done <- &CmdStatus{
-				isSuccess: false,
-				exitCode:  exitError.ExitCode(),
-				errMsg:    errorMsg,
-			}
-			return errors.New(errorMsg)
-		} else {
-			log.Printf("cmd.Wait() error: %v", err)
+			status.exitCode = exitError.ExitCode()
+		} else if status.errMsg == "" {
+			status.errMsg = err.Error()
 		}
+		return status
 	}
-	r.done <- &CmdStatus{
-		isSuccess: true,
-		exitCode:  0,
-	}
+	status.isSuccess = true
+	return status
+}
 
-	return nil
+// printSummary 列出每个命令的执行结果
+func (r *CommandRunner) printSummary() {
+	fmt.Println("Summary:")
+	for i, status := range r.statuses {
+		var result string
+		switch {
+		case status == nil:
+			fmt.Printf("  [%s] skipped\n", r.Commands[i].Name)
+			continue
+		case status.isSuccess:
+			result = aec.Apply("ok", outputColor)
+		case status.canceled:
+			fmt.Printf("  [%s] %s (%s)\n", status.name, aec.Apply("canceled", errColor), status.duration.Round(time.Millisecond))
+			continue
+		case status.timedOut:
+			fmt.Printf("  [%s] %s (after %s)\n", status.name, aec.Apply("timed out", errColor), status.timeout)
+			continue
+		default:
+			result = aec.Apply("failed", errColor)
+		}
+		fmt.Printf("  [%s] %s (exit %d, %s)\n", status.name, result, status.exitCode, status.duration.Round(time.Millisecond))
+	}
 }
 
 type CommandRunner struct {
-	output chan string
-	done   chan *CmdStatus
-
-	Commands []Command
-
-	err error
-	wg  *sync.WaitGroup
-	d   *display
+	events chan event
+
+	Commands  []Command
+	Parallel  int           // 同时执行的命令数，小于1时逐个执行
+	KeepGoing bool          // 命令失败后继续执行其余命令，最后汇总结果
+	Timeout   time.Duration // 未设置Timeout的命令的超时时间，0表示不限制
+	Shell     string        // 未设置Shell的命令使用的shell，为空时unix使用bash，Windows使用cmd
+	NoShell   bool          // 未设置Shell的命令不经过shell直接执行
+
+	statuses []*CmdStatus
+	err      error
+	wg       *sync.WaitGroup
+	d        *display
 }
 
 func NewCommandRunner(commands []Command) *CommandRunner {
-	output := make(chan string)
-	done := make(chan *CmdStatus)
-	clearDone := make(chan struct{})
+	events := make(chan event)
 	wg := sync.WaitGroup{}
 
 	return &CommandRunner{
 		Commands: commands,
-		output:   output,
-		done:     done,
+		events:   events,
+		statuses: make([]*CmdStatus, len(commands)),
 		wg:       &wg,
 		d: &display{
-			output:    output,
-			done:      done,
-			wg:        &wg,
-			clear:     clearDone,
-			cmdCnt:    len(commands),
-			doneCnt:   0,
-			isHidden:  true,
-			prevLines: 0,
-			buffer:    make([]string, 0),
-			ticker:    time.NewTicker(200 * time.Millisecond),
+			events:  events,
+			wg:      &wg,
+			names:   commandNames(commands),
+			running: make(map[int][]string),
+			ticker:  time.NewTicker(200 * time.Millisecond),
 		},
 	}
 }
 
+func commandNames(commands []Command) []string {
+	names := make([]string, len(commands))
+	for i, c := range commands {
+		names[i] = c.Name
+	}
+	return names
+}
+
+// display 是唯一向终端输出的goroutine：已结束的命令输出为固定的结果行，
+// 正在执行的命令在底部显示标题和最近几行输出，定时刷新
 type display struct {
-	output chan string
-	done   chan *CmdStatus
-	clear  chan struct{}
+	events chan event
 	ticker *time.Ticker
 
 	wg *sync.WaitGroup
 
-	cmdCnt  int
-	doneCnt int
+	names   []string
+	order   []int            // 正在执行的命令，按开始顺序
+	running map[int][]string // 正在执行的命令最近的输出
 
-	isHidden    bool
-	prevLines   int
-	buffer      []string
-	bufferMutex sync.Mutex
+	prevLines int
 }
 
+// maxTailLines 动态区域显示的输出行数，多个命令同时执行时平分
+const maxTailLines = 6
+
 func (d *display) update() {
 	defer d.wg.Done()
-	defer close(d.clear)
+	defer d.ticker.Stop()
 
 	for {
 		select {
 		case <-d.ticker.C:
-			d.print()
-		case status := <-d.done:
-			d.cleanUp()
-
-			d.doneCnt++
-			if !status.isSuccess || d.doneCnt == d.cmdCnt {
+			d.print(nil)
+		case e, ok := <-d.events:
+			if !ok {
+				d.print(nil)
 				return
 			}
+			d.handle(e)
 		}
 	}
 }
 
-func (d *display) refreshBuffer() {
-	defer d.wg.Done()
-	for line := range d.output {
-		d.bufferMutex.Lock()
-		d.buffer = append(d.buffer, line)
-		if len(d.buffer) > 6 {
-			d.buffer = d.buffer[1:]
+func (d *display) handle(e event) {
+	switch {
+	case e.started:
+		d.running[e.idx] = nil
+		d.order = append(d.order, e.idx)
+		d.print(nil)
+	case e.status != nil:
+		d.finish(e.idx, e.status)
+	default:
+		tail := append(d.running[e.idx], e.line)
+		if limit := d.tailLines(); len(tail) > limit {
+			tail = tail[len(tail)-limit:]
+		}
+		d.running[e.idx] = tail
+	}
+}
+
+// tailLines 每个正在执行的命令显示的输出行数
+func (d *display) tailLines() int {
+	return max(1, maxTailLines/max(1, len(d.order)))
+}
+
+// finish 将结束的命令移出动态区域并输出结果行
+func (d *display) finish(idx int, status *CmdStatus) {
+	tail := d.running[idx]
+	delete(d.running, idx)
+	for i, o := range d.order {
+		if o == idx {
+			d.order = append(d.order[:i], d.order[i+1:]...)
+			break
+		}
+	}
+
+	out := fmt.Sprintf("Executing [%s]...", d.names[idx])
+	var lines []string
+	switch {
+	case status.isSuccess:
+		lines = []string{aec.Apply(out+" done", outputColor)}
+	case status.canceled:
+		lines = []string{aec.Apply(out+" canceled", errColor)}
+	case status.timedOut:
+		// 输出超时前最近的输出，便于判断命令卡在哪里
+		lines = []string{aec.Apply(out+" timed out", errColor)}
+		for _, l := range tail {
+			lines = append(lines, aec.Apply(l, aec.Faint))
+		}
+		lines = append(lines, aec.Apply("Error:", errColor), fmt.Sprintf("timed out after %s", status.timeout))
+		if msg := strings.TrimRight(status.errMsg, "\n"); msg != "" {
+			lines = append(lines, strings.Split(msg, "\n")...)
+		}
+	default:
+		msg := strings.TrimRight(status.errMsg, "\n")
+		if msg == "" {
+			msg = fmt.Sprintf("exit status %d", status.exitCode)
 		}
-		d.bufferMutex.Unlock()
+		lines = []string{aec.Apply(out, outputColor), aec.Apply("Error:", errColor)}
+		lines = append(lines, strings.Split(msg, "\n")...)
 	}
+	d.print(lines)
 }
 
-func (d *display) print() {
-	d.bufferMutex.Lock()
+// print 重绘动态区域，fixed为需要在动态区域上方固定输出的行
+func (d *display) print(fixed []string) {
 	if d.prevLines > 0 {
 		fmt.Printf(ANSI_MOVE_UP_LINES, d.prevLines)
 	}
 
-	currentLines := len(d.buffer)
-	if currentLines > 0 {
-		d.isHidden = false
-	}
-	for _, l := range d.buffer {
-		out := aec.Apply(l, aec.Faint)
-		fmt.Println(ANSI_CLEAR_LINE, out)
+	var live []string
+	for _, idx := range d.order {
+		live = append(live, aec.Apply(fmt.Sprintf("Executing [%s]...", d.names[idx]), outputColor))
+		for _, l := range d.running[idx] {
+			live = append(live, aec.Apply(l, aec.Faint))
+		}
 	}
 
-	d.prevLines = currentLines
-	d.bufferMutex.Unlock()
-}
+	for _, l := range fixed {
+		fmt.Println(ANSI_CLEAR_LINE + l)
+	}
+	for _, l := range live {
+		fmt.Println(ANSI_CLEAR_LINE + l)
+	}
 
-func (d *display) cleanUp() {
-	d.bufferMutex.Lock()
-	if !d.isHidden {
-		fmt.Printf(ANSI_MOVE_UP_LINES, d.prevLines)
-		for i := 0; i < d.prevLines; i++ {
+	// 清除上次多出来的行
+	if extra := d.prevLines - len(fixed) - len(live); extra > 0 {
+		for i := 0; i < extra; i++ {
 			fmt.Println(ANSI_CLEAR_LINE)
 		}
-		fmt.Printf(ANSI_MOVE_UP_LINES, d.prevLines)
-		d.prevLines = 0
-		d.buffer = nil
-		d.isHidden = true
+		fmt.Printf(ANSI_MOVE_UP_LINES, extra)
 	}
-	d.bufferMutex.Unlock()
-	d.clear <- struct{}{}
+	d.prevLines = len(live)
 }
 
 func main() {
@@ -0,0 +1,175 @@
+//go:build !windows
+
+package runner
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// runAll 执行全部命令并收集每个命令的输出行，不经过终端显示
+func runAll(t *testing.T, r *CommandRunner) map[int][]string {
+	t.Helper()
+	lines := map[int][]string{}
+	r.wg.Add(1)
+	go r.runCommands()
+	for e := range r.events {
+		if e.line != "" {
+			lines[e.idx] = append(lines[e.idx], e.line)
+		}
+	}
+	r.wg.Wait()
+	return lines
+}
+
+func shellCommands(cmdLines ...string) []Command {
+	commands := make([]Command, len(cmdLines))
+	for i, c := range cmdLines {
+		commands[i] = Command{Name: c, CmdLine: c, Shell: "sh"}
+	}
+	return commands
+}
+
+func TestRunTimeoutKeepsPartialOutput(t *testing.T) {
+	r := NewCommandRunner(shellCommands("echo started; sleep 10"))
+	r.Timeout = 200 * time.Millisecond
+	start := time.Now()
+	lines := runAll(t, r)
+
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("timeout took %s", d)
+	}
+	status := r.statuses[0]
+	if !status.timedOut || status.isSuccess {
+		t.Fatalf("status = %+v, want timed out", status)
+	}
+	if r.err == nil || !strings.Contains(r.err.Error(), "timed out after 200ms") {
+		t.Errorf("err = %v", r.err)
+	}
+	if !reflect.DeepEqual(lines[0], []string{"started"}) {
+		t.Errorf("output = %q, want the line printed before the timeout", lines[0])
+	}
+}
+
+// 超时结束整个进程组，后台子进程不会继续占用输出管道直到WaitDelay到期
+func TestRunTimeoutKillsProcessGroup(t *testing.T) {
+	defer func(d time.Duration) { waitDelay = d }(waitDelay)
+	waitDelay = 10 * time.Second
+
+	r := NewCommandRunner(shellCommands("sleep 10 & sleep 10; wait"))
+	r.Timeout = 200 * time.Millisecond
+	start := time.Now()
+	runAll(t, r)
+
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("killing the process group took %s", d)
+	}
+	if !r.statuses[0].timedOut {
+		t.Errorf("status = %+v, want timed out", r.statuses[0])
+	}
+}
+
+// 命令正常退出后留下的子进程仍占用输出时，WaitDelay到期后结束读取，命令视为成功
+func TestRunLeftoverChildHoldingOutput(t *testing.T) {
+	defer func(d time.Duration) { waitDelay = d }(waitDelay)
+	waitDelay = 200 * time.Millisecond
+
+	r := NewCommandRunner(shellCommands("sleep 10 & echo done"))
+	start := time.Now()
+	lines := runAll(t, r)
+
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("run took %s", d)
+	}
+	if !r.statuses[0].isSuccess || r.err != nil {
+		t.Errorf("status = %+v, err = %v; want success", r.statuses[0], r.err)
+	}
+	if !reflect.DeepEqual(lines[0], []string{"done"}) {
+		t.Errorf("output = %q", lines[0])
+	}
+}
+
+func TestRunFailureOutput(t *testing.T) {
+	r := NewCommandRunner(shellCommands("echo one; echo two >&2; exit 2"))
+	lines := runAll(t, r)
+
+	status := r.statuses[0]
+	if status.isSuccess || status.exitCode != 2 || status.errMsg != "two\n" {
+		t.Errorf("status = %+v, want exit 2 with stderr", status)
+	}
+	if !reflect.DeepEqual(lines[0], []string{"one"}) {
+		t.Errorf("output = %q", lines[0])
+	}
+}
+
+func TestRunFailFast(t *testing.T) {
+	r := NewCommandRunner(shellCommands("exit 3", "echo second"))
+	runAll(t, r)
+	if r.err == nil || r.err.Error() != "[exit 3] exit status 3" {
+		t.Errorf("err = %v", r.err)
+	}
+	if r.statuses[1] != nil {
+		t.Errorf("second command ran after the first failed: %+v", r.statuses[1])
+	}
+
+	// 并行执行时其余正在执行的命令被取消
+	r = NewCommandRunner(shellCommands("sleep 0.1; exit 1", "sleep 10"))
+	r.Parallel = 2
+	start := time.Now()
+	runAll(t, r)
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("cancel took %s", d)
+	}
+	if !r.statuses[1].canceled {
+		t.Errorf("running command = %+v, want canceled", r.statuses[1])
+	}
+}
+
+func TestRunParallelKeepGoing(t *testing.T) {
+	r := NewCommandRunner(shellCommands("sleep 0.5", "exit 1", "sleep 0.5", "exit 2"))
+	r.Parallel = 4
+	r.KeepGoing = true
+	start := time.Now()
+	runAll(t, r)
+
+	// 四个命令同时执行，总耗时接近单个命令
+	if d := time.Since(start); d > 900*time.Millisecond {
+		t.Errorf("parallel run took %s", d)
+	}
+	if r.err == nil || r.err.Error() != "2 of 4 commands failed" {
+		t.Errorf("err = %v", r.err)
+	}
+	for i, status := range r.statuses {
+		if status == nil || status.canceled || status.isSuccess != (i%2 == 0) {
+			t.Errorf("command %d: status = %+v", i, status)
+		}
+	}
+}
+
+func TestRunDirAndEnv(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("RUNNER_INHERITED", "yes")
+	r := NewCommandRunner([]Command{{
+		Name:    "env",
+		CmdLine: `pwd; echo "$RUNNER_TEST $RUNNER_INHERITED"`,
+		Shell:   "sh",
+		Dir:     dir,
+		Env:     []string{"RUNNER_TEST=set"},
+	}})
+	lines := runAll(t, r)
+	if want := []string{dir, "set yes"}; !reflect.DeepEqual(lines[0], want) {
+		t.Errorf("output = %q, want %q", lines[0], want)
+	}
+
+	r = NewCommandRunner([]Command{{Name: "missing", CmdLine: "pwd", Shell: "sh", Dir: filepath.Join(dir, "missing")}})
+	runAll(t, r)
+	if msg := r.statuses[0].errMsg; !strings.HasPrefix(msg, "working directory:") {
+		t.Errorf("errMsg = %q", msg)
+	}
+}
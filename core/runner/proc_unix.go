@@ -0,0 +1,20 @@
+//go:build !windows
+
+package runner
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup 让命令在独立的进程组中运行，取消或超时时结束整个进程组，
+// 避免shell启动的子进程继续运行并占用输出管道
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
+
+// setShellCommandLine Unix下参数数组直接传给shell，不会重新解析命令行，无需处理
+func setShellCommandLine(cmd *exec.Cmd, shell []string, cmdLine string) {}
@@ -0,0 +1,29 @@
+//go:build windows
+
+package runner
+
+import (
+	"os/exec"
+	"strings"
+	"syscall"
+)
+
+// setProcessGroup Windows没有进程组，取消或超时时只结束shell本身，
+// shell启动的子进程占用的输出管道由WaitDelay到期后关闭
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// setShellCommandLine cmd不按Windows的常规规则解析参数，
+// 经过转义的命令行会被破坏，因此原样传入命令行
+func setShellCommandLine(cmd *exec.Cmd, shell []string, cmdLine string) {
+	if shellFlag(shell[0]) != "/c" {
+		return
+	}
+	parts := make([]string, len(shell))
+	for i, s := range shell {
+		parts[i] = syscall.EscapeArg(s)
+	}
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.CmdLine = strings.Join(parts, " ") + " " + cmdLine
+}
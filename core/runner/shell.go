@@ -0,0 +1,119 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// defaultShell 未指定shell时使用的shell
+func defaultShell() string {
+	if runtime.GOOS == "windows" {
+		return "cmd"
+	}
+	return "bash"
+}
+
+// shellFlag 只给出shell程序名时，用于传入命令行的参数
+func shellFlag(shell string) string {
+	name := strings.TrimSuffix(strings.ToLower(filepath.Base(shell)), ".exe")
+	switch name {
+	case "cmd":
+		return "/c"
+	case "powershell", "pwsh":
+		return "-Command"
+	}
+	return "-c"
+}
+
+// newCmd 创建执行命令的exec.Cmd：命令的设置优先于runner的设置，
+// 不使用shell时按shell的规则拆分命令行，直接执行第一个参数对应的程序
+func (r *CommandRunner) newCmd(ctx context.Context, command Command) (*exec.Cmd, error) {
+	if command.NoShell || (command.Shell == "" && r.NoShell) {
+		args, err := splitCommandLine(command.CmdLine)
+		if err != nil {
+			return nil, err
+		}
+		if len(args) == 0 {
+			return nil, errors.New("empty command line")
+		}
+		return exec.CommandContext(ctx, args[0], args[1:]...), nil
+	}
+
+	shell := command.Shell
+	if shell == "" {
+		shell = r.Shell
+	}
+	if shell == "" {
+		shell = defaultShell()
+	}
+	// 只给出程序名时补上对应的参数，如 "zsh" 为 "zsh -c"，否则按给出的参数执行，如 "bash -lc"
+	args := strings.Fields(shell)
+	if len(args) == 1 {
+		args = append(args, shellFlag(args[0]))
+	}
+	cmd := exec.CommandContext(ctx, args[0], append(args[1:], command.CmdLine)...)
+	setShellCommandLine(cmd, args, command.CmdLine)
+	return cmd, nil
+}
+
+// splitCommandLine 按POSIX shell的规则拆分命令行：支持单引号、双引号和反斜杠转义，
+// 不支持变量、通配符、管道和重定向
+func splitCommandLine(s string) ([]string, error) {
+	var args []string
+	var cur strings.Builder
+	inArg := false
+	var quote rune
+
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		switch {
+		case quote == '\'':
+			if c == '\'' {
+				quote = 0
+			} else {
+				cur.WriteRune(c)
+			}
+		case quote == '"':
+			switch {
+			case c == '"':
+				quote = 0
+			case c == '\\' && i+1 < len(runes) && strings.ContainsRune(`"\$`+"`", runes[i+1]):
+				i++
+				cur.WriteRune(runes[i])
+			default:
+				cur.WriteRune(c)
+			}
+		case c == '\'' || c == '"':
+			quote = c
+			inArg = true
+		case c == '\\':
+			if i+1 < len(runes) {
+				i++
+				cur.WriteRune(runes[i])
+			}
+			inArg = true
+		case c == ' ' || c == '\t' || c == '\n':
+			if inArg {
+				args = append(args, cur.String())
+				cur.Reset()
+				inArg = false
+			}
+		default:
+			cur.WriteRune(c)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote in command line", quote)
+	}
+	if inArg {
+		args = append(args, cur.String())
+	}
+	return args, nil
+}
@@ -0,0 +1,56 @@
+package runner
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitCommandLine(t *testing.T) {
+	cases := []struct {
+		in   string
+		want []string
+	}{
+		{"", nil},
+		{"  ls  -la ", []string{"ls", "-la"}},
+		{`echo 'hello world'`, []string{"echo", "hello world"}},
+		{`echo "a \"b\" \n"`, []string{"echo", `a "b" \n`}},
+		{`echo a\ b`, []string{"echo", "a b"}},
+		{`echo '' x`, []string{"echo", "", "x"}},
+		{`git commit -m "fix: it's done"`, []string{"git", "commit", "-m", "fix: it's done"}},
+		{`a"b"'c'`, []string{"abc"}},
+	}
+	for _, c := range cases {
+		got, err := splitCommandLine(c.in)
+		if err != nil {
+			t.Errorf("splitCommandLine(%q): %v", c.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("splitCommandLine(%q) = %q, want %q", c.in, got, c.want)
+		}
+	}
+}
+
+func TestSplitCommandLineUnterminated(t *testing.T) {
+	for _, in := range []string{`echo "abc`, `echo 'abc`} {
+		if _, err := splitCommandLine(in); err == nil {
+			t.Errorf("splitCommandLine(%q): expected error", in)
+		}
+	}
+}
+
+func TestShellFlag(t *testing.T) {
+	cases := map[string]string{
+		"bash":       "-c",
+		"/bin/zsh":   "-c",
+		"cmd":        "/c",
+		"CMD.EXE":    "/c",
+		"powershell": "-Command",
+		"pwsh.exe":   "-Command",
+	}
+	for shell, want := range cases {
+		if got := shellFlag(shell); got != want {
+			t.Errorf("shellFlag(%q) = %q, want %q", shell, got, want)
+		}
+	}
+}
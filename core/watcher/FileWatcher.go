@@ -8,6 +8,8 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
+	"sync"
 	"time"
 )
 
@@ -16,7 +18,9 @@ type FileWatcher struct {
 	path      string
 	interval  time.Duration
 	stopChan  chan struct{}
+	mu        sync.RWMutex
 	lastState map[string]FileState // 文件路径 -> 状态
+	filter    EventFilter
 }
 
 type FileState struct {
@@ -49,7 +53,10 @@ func (w *FileWatcher) Watch(ctx context.Context) (<-chan Event, error) {
 		for {
 			select {
 			case <-ticker.C:
-				w.detectChanges(eventCh)
+				if !w.detectChanges(eventCh) {
+					// 根路径丢失，关闭事件通道，由WatchServer重连
+					return
+				}
 			case <-w.stopChan:
 				return
 			case <-ctx.Done():
@@ -61,10 +68,25 @@ func (w *FileWatcher) Watch(ctx context.Context) (<-chan Event, error) {
 	return eventCh, nil
 }
 
+// SetFilter 设置事件过滤函数，需在Watch之前调用，nil表示不过滤
+func (w *FileWatcher) SetFilter(filter EventFilter) {
+	w.filter = filter
+}
+
 func (w *FileWatcher) Stop() {
 	close(w.stopChan)
 }
 
+// Recoverable 未被Stop时允许WatchServer重连
+func (w *FileWatcher) Recoverable() bool {
+	select {
+	case <-w.stopChan:
+		return false
+	default:
+		return true
+	}
+}
+
 func (w *FileWatcher) List() ([]interface{}, error) {
 	stateMap, err := scanPath(w.path)
 	if err != nil {
@@ -162,10 +184,32 @@ func (w *FileWatcher) scanFiles() error {
 		return err
 	}
 
+	w.mu.Lock()
 	w.lastState = stateMap
+	w.mu.Unlock()
 	return nil
 }
 
+// Duplicates 基于最近一次扫描的状态返回校验和相同的文件，校验和 -> 文件路径列表（已排序）
+// 只包含两个及以上路径共享的校验和；校验和仅覆盖文件前8KB，结果需结合文件大小进一步确认
+func (w *FileWatcher) Duplicates() map[string][]string {
+	w.mu.RLock()
+	index := make(map[string][]string)
+	for path, state := range w.lastState {
+		index[state.Checksum] = append(index[state.Checksum], path)
+	}
+	w.mu.RUnlock()
+
+	for checksum, paths := range index {
+		if len(paths) < 2 {
+			delete(index, checksum)
+			continue
+		}
+		sort.Strings(paths)
+	}
+	return index
+}
+
 // calculateChecksum 计算文件的MD5校验和
 // 为了效率，只读取文件的前8KB来计算校验和，这在大多数情况下足够检测文件变化
 // 返回十六进制编码的MD5哈希值字符串
@@ -188,26 +232,26 @@ func calculateChecksum(filePath string) (string, error) {
 }
 
 // compareStates 比较两个状态映射并发送相应的事件
-func compareStates(currentState, lastState map[string]FileState, eventCh chan<- Event) {
+func compareStates(currentState, lastState map[string]FileState, eventCh chan<- Event, filter EventFilter) {
 	// 检测新增和修改的文件
 	for path, state := range currentState {
 		oldState, exists := lastState[path]
 		if !exists {
 			// 新增文件
-			eventCh <- Event{
+			sendEvent(eventCh, filter, Event{
 				Type:      Added,
 				Object:    path,
 				Timestamp: time.Now(),
-			}
+			})
 		} else if oldState.Size != state.Size ||
-			oldState.ModTime != state.ModTime ||
+			!oldState.ModTime.Equal(state.ModTime) ||
 			oldState.Checksum != state.Checksum {
 			// 修改文件 - 明确比较各个字段
-			eventCh <- Event{
+			sendEvent(eventCh, filter, Event{
 				Type:      Modified,
 				Object:    path,
 				Timestamp: time.Now(),
-			}
+			})
 		}
 	}
 
@@ -215,27 +259,53 @@ func compareStates(currentState, lastState map[string]FileState, eventCh chan<-
 	for path := range lastState {
 		if _, exists := currentState[path]; !exists {
 			// 删除文件
-			eventCh <- Event{
+			sendEvent(eventCh, filter, Event{
 				Type:      Deleted,
 				Object:    path,
 				Timestamp: time.Now(),
-			}
+			})
 		}
 	}
 }
 
-// detectChanges 扫描文件系统并检测变化，将变化事件发送到eventCh
-func (w *FileWatcher) detectChanges(eventCh chan<- Event) {
+// detectChanges 扫描文件系统并检测变化，将变化事件发送到eventCh；
+// 根路径不存在或不可读（如NFS断开、重新挂载）时发送Error事件并返回false
+func (w *FileWatcher) detectChanges(eventCh chan<- Event) bool {
 	// 扫描文件系统，获取当前状态
 	currentState, err := scanPath(w.path)
 	if err != nil {
 		handleError(err, fmt.Sprintf("Failed to scan path %s", w.path), eventCh)
-		return
+		// 扫描中途有文件被删除等错误只影响本次扫描，继续监控
+		return checkRoot(w.path) == nil
 	}
 
 	// 比较状态并发送事件
-	compareStates(currentState, w.lastState, eventCh)
+	w.mu.RLock()
+	lastState := w.lastState
+	w.mu.RUnlock()
+	compareStates(currentState, lastState, eventCh, w.filter)
 
 	// 更新状态
+	w.mu.Lock()
 	w.lastState = currentState
+	w.mu.Unlock()
+	return true
+}
+
+// checkRoot 检查监控的根路径是否存在且可读，目录需能列出内容
+func checkRoot(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil || !info.IsDir() {
+		return err
+	}
+	if _, err := f.Readdirnames(1); err != nil && err != io.EOF {
+		return err
+	}
+	return nil
 }
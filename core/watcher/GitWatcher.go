@@ -2,14 +2,22 @@ package watcher
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
 	"github.com/go-git/go-git/v5/plumbing/transport/http"
 )
 
+const (
+	defaultGitRetryBase  = time.Second
+	defaultGitMaxRetries = 3
+)
+
 type GitWatcher struct {
 	repoPath string
 	remote   string
@@ -19,6 +27,13 @@ type GitWatcher struct {
 	stopChan chan struct{}
 	lastHash string
 	repo     *git.Repository
+
+	// 远程操作失败时的重试参数
+	retryBase  time.Duration
+	maxRetries int
+	// 遇到认证等不可恢复的错误后置位，WatchServer不再重连
+	failed atomic.Bool
+	filter EventFilter
 }
 
 func NewGitWatcher(repoPath, remote, branch string, auth *http.BasicAuth, interval time.Duration) *GitWatcher {
@@ -32,6 +47,9 @@ func NewGitWatcher(repoPath, remote, branch string, auth *http.BasicAuth, interv
 		auth:     auth,
 		interval: interval,
 		stopChan: make(chan struct{}),
+
+		retryBase:  defaultGitRetryBase,
+		maxRetries: defaultGitMaxRetries,
 	}
 }
 
@@ -42,8 +60,15 @@ func (w *GitWatcher) Watch(ctx context.Context) (<-chan Event, error) {
 		return nil, err
 	}
 
-	hash, err := w.getRemoteHash()
+	var hash string
+	err := w.withRetry(func() (err error) {
+		hash, err = w.getRemoteHash()
+		return err
+	})
 	if err != nil {
+		if isPermanentGitError(err) {
+			w.failed.Store(true)
+		}
 		return nil, err
 	}
 	w.lastHash = hash
@@ -56,7 +81,9 @@ func (w *GitWatcher) Watch(ctx context.Context) (<-chan Event, error) {
 		for {
 			select {
 			case <-ticker.C:
-				w.checkForUpdate(eventCh)
+				if !w.checkForUpdate(eventCh) {
+					return
+				}
 			case <-w.stopChan:
 				return
 			case <-ctx.Done():
@@ -68,10 +95,28 @@ func (w *GitWatcher) Watch(ctx context.Context) (<-chan Event, error) {
 	return eventCh, nil
 }
 
+// SetFilter 设置事件过滤函数，需在Watch之前调用，nil表示不过滤
+func (w *GitWatcher) SetFilter(filter EventFilter) {
+	w.filter = filter
+}
+
 func (w *GitWatcher) Stop() {
 	close(w.stopChan)
 }
 
+// Recoverable 未被Stop且未遇到不可恢复的错误时允许WatchServer重连
+func (w *GitWatcher) Recoverable() bool {
+	if w.failed.Load() {
+		return false
+	}
+	select {
+	case <-w.stopChan:
+		return false
+	default:
+		return true
+	}
+}
+
 func (w *GitWatcher) List() ([]interface{}, error) {
 	return []interface{}{w.repoPath}, nil
 }
@@ -114,28 +159,71 @@ func (w *GitWatcher) getRemoteHash() (string, error) {
 	return "", fmt.Errorf("branch %s not found on remote %s", w.branch, remoteName)
 }
 
-func (w *GitWatcher) checkForUpdate(eventCh chan<- Event) {
-	currentHash, err := w.getRemoteHash()
+// checkForUpdate 检查远程分支是否有更新，返回false表示遇到不可恢复的错误，应停止监听
+func (w *GitWatcher) checkForUpdate(eventCh chan<- Event) bool {
+	var currentHash string
+	err := w.withRetry(func() (err error) {
+		currentHash, err = w.getRemoteHash()
+		return err
+	})
 	if err != nil {
-		eventCh <- Event{Type: Error, Object: err.Error(), Timestamp: time.Now()}
-		return
+		return w.reportError(eventCh, err)
 	}
 
 	if currentHash != w.lastHash {
-		if err := w.pullChanges(); err != nil {
-			eventCh <- Event{Type: Error, Object: err.Error(), Timestamp: time.Now()}
-			return
+		if err := w.withRetry(w.pullChanges); err != nil {
+			return w.reportError(eventCh, err)
 		}
 
 		w.lastHash = currentHash
-		eventCh <- Event{
+		sendEvent(eventCh, w.filter, Event{
 			Type:      Modified,
 			Object:    "Git repository updated",
 			Timestamp: time.Now(),
+		})
+	}
+	return true
+}
+
+// reportError 发送错误事件，不可恢复的错误会标记watcher失效并返回false
+func (w *GitWatcher) reportError(eventCh chan<- Event, err error) bool {
+	if isPermanentGitError(err) {
+		w.failed.Store(true)
+		eventCh <- Event{
+			Type:      Error,
+			Object:    fmt.Sprintf("git watcher for %s stopped: %v", w.repoPath, err),
+			Timestamp: time.Now(),
+		}
+		return false
+	}
+	eventCh <- Event{Type: Error, Object: err.Error(), Timestamp: time.Now()}
+	return true
+}
+
+// withRetry 以指数退避重试远程操作，不可恢复的错误或Stop时立即返回
+func (w *GitWatcher) withRetry(op func() error) error {
+	backoff := w.retryBase
+	for attempt := 0; ; attempt++ {
+		err := op()
+		if err == nil || isPermanentGitError(err) || attempt >= w.maxRetries {
+			return err
 		}
+		select {
+		case <-time.After(backoff):
+		case <-w.stopChan:
+			return err
+		}
+		backoff *= 2
 	}
 }
 
+// isPermanentGitError 认证失败、无权限、仓库不存在等错误重试无意义
+func isPermanentGitError(err error) bool {
+	return errors.Is(err, transport.ErrAuthenticationRequired) ||
+		errors.Is(err, transport.ErrAuthorizationFailed) ||
+		errors.Is(err, transport.ErrRepositoryNotFound)
+}
+
 func (w *GitWatcher) pullChanges() error {
 	if w.repo == nil {
 		return fmt.Errorf("repository not initialized")
@@ -0,0 +1,62 @@
+package watcher
+
+import (
+	"context"
+	"time"
+)
+
+// Batch 将事件通道合并为批次：距最后一个事件静默quiet后，或累计达到maxSize个事件时输出一批
+// maxSize<=0表示不限制批次大小；输入通道关闭时输出剩余事件并关闭输出通道
+func Batch(ctx context.Context, in <-chan Event, quiet time.Duration, maxSize int) <-chan []Event {
+	out := make(chan []Event)
+
+	go func() {
+		defer close(out)
+
+		var batch []Event
+		timer := time.NewTimer(quiet)
+		timer.Stop()
+		defer timer.Stop()
+
+		flush := func() bool {
+			if len(batch) == 0 {
+				return true
+			}
+			select {
+			case out <- batch:
+				batch = nil
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for {
+			select {
+			case event, ok := <-in:
+				if !ok {
+					flush()
+					return
+				}
+				batch = append(batch, event)
+				if maxSize > 0 && len(batch) >= maxSize {
+					timer.Stop()
+					if !flush() {
+						return
+					}
+					continue
+				}
+				// 每个新事件重新开始计算静默期
+				timer.Reset(quiet)
+			case <-timer.C:
+				if !flush() {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
@@ -102,6 +102,14 @@ func (s *EventStore) GetEventsAfter(key ResourceKey, resourceVersion string) ([]
 	return result, nil
 }
 
+// Len 返回指定资源当前存储的事件数
+func (s *EventStore) Len(key ResourceKey) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.events[key])
+}
+
 func versionedObject(obj interface{}, version string) interface{} {
 	return struct {
 		Object          interface{} `json:"object"`
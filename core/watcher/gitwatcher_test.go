@@ -1,12 +1,15 @@
 package watcher
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/transport"
 )
 
 func setupGitOrigin(t *testing.T, dir string) *git.Repository {
@@ -168,3 +171,40 @@ func TestGitWatcherList(t *testing.T) {
 		t.Errorf("expected %s, got %v", dir, list[0])
 	}
 }
+
+func TestGitWatcherRetry(t *testing.T) {
+	gw := NewGitWatcher(t.TempDir(), "", "", nil, time.Second)
+	gw.retryBase = time.Millisecond
+
+	calls := 0
+	err := gw.withRetry(func() error {
+		calls++
+		if calls < 3 {
+			return errors.New("connection reset")
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Fatalf("expected success after 3 calls, got %d calls, err=%v", calls, err)
+	}
+
+	calls = 0
+	err = gw.withRetry(func() error {
+		calls++
+		return fmt.Errorf("failed to list remote origin refs: %w", transport.ErrAuthenticationRequired)
+	})
+	if err == nil || calls != 1 {
+		t.Fatalf("expected permanent error without retry, got %d calls, err=%v", calls, err)
+	}
+
+	eventCh := make(chan Event, 1)
+	if gw.reportError(eventCh, err) {
+		t.Error("expected reportError to stop on auth failure")
+	}
+	if ev := <-eventCh; ev.Type != Error {
+		t.Errorf("expected Error event, got %s", ev.Type)
+	}
+	if gw.Recoverable() {
+		t.Error("watcher should not be recoverable after auth failure")
+	}
+}
@@ -0,0 +1,110 @@
+package watcher
+
+import (
+	"fmt"
+	"time"
+)
+
+// defaultHandlerQueue 异步处理器的事件队列长度
+const defaultHandlerQueue = 100
+
+// registeredHandler 服务端事件处理器，queue为nil时在分发协程中同步执行
+type registeredHandler struct {
+	fn    EventHandler
+	queue chan Event
+}
+
+// AddHandler 为资源注册同步事件处理器，在事件分发给订阅者之后依次执行
+func (s *WatchServer) AddHandler(key ResourceKey, handler EventHandler) error {
+	return s.AddHandlerPool(key, handler, 0)
+}
+
+// AddHandlerPool 为资源注册事件处理器，由workers个协程并发执行；workers<=0时同步执行
+// 队列满时丢弃事件并计入统计的HandlerDropped，不阻塞分发；处理器中的panic会被恢复并以Error事件通知订阅者。
+// 协程在Stop时处理完队列中剩余的事件后退出
+func (s *WatchServer) AddHandlerPool(key ResourceKey, handler EventHandler, workers int) error {
+	if handler == nil {
+		return fmt.Errorf("nil handler for %v", key)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.watchers[key]; !exists {
+		return fmt.Errorf("no watcher registered for %v", key)
+	}
+	if s.ctx.Err() != nil {
+		return fmt.Errorf("watch server stopped")
+	}
+
+	h := &registeredHandler{fn: handler}
+	if workers > 0 {
+		h.queue = make(chan Event, defaultHandlerQueue)
+		s.workers.Add(workers)
+		for i := 0; i < workers; i++ {
+			go func() {
+				defer s.workers.Done()
+				for event := range h.queue {
+					s.runHandler(key, h.fn, event)
+				}
+			}()
+		}
+	}
+	s.handlers[key] = append(s.handlers[key], h)
+	return nil
+}
+
+// dispatchHandlers 将事件交给资源的所有处理器，Stop之后不再分发
+func (s *WatchServer) dispatchHandlers(key ResourceKey, event Event) {
+	s.mu.RLock()
+	if s.ctx.Err() != nil {
+		s.mu.RUnlock()
+		return
+	}
+	handlers := s.handlers[key]
+	// 在读锁下入队，Stop持有写锁关闭队列，不会向已关闭的队列发送
+	for _, h := range handlers {
+		if h.queue == nil {
+			continue
+		}
+		select {
+		case h.queue <- event:
+		default:
+			if c := s.counters[key]; c != nil {
+				c.handlerDropped.Add(1)
+			}
+		}
+	}
+	s.mu.RUnlock()
+
+	for _, h := range handlers {
+		if h.queue == nil {
+			s.runHandler(key, h.fn, event)
+		}
+	}
+}
+
+// closeHandlers 关闭所有异步处理器的队列，调用方需持有s.mu写锁
+func (s *WatchServer) closeHandlers() {
+	for _, handlers := range s.handlers {
+		for _, h := range handlers {
+			if h.queue != nil {
+				close(h.queue)
+			}
+		}
+	}
+}
+
+// runHandler 执行处理器并恢复panic
+func (s *WatchServer) runHandler(key ResourceKey, handler EventHandler, event Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.broadcast(key, Event{
+				Type:      Error,
+				Object:    fmt.Sprintf("event handler for %v panicked: %v", key, r),
+				Timestamp: time.Now(),
+			})
+		}
+	}()
+	handler(event)
+}
@@ -0,0 +1,134 @@
+package watcher
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// gitSnapshot GitWatcher持久化的快照内容
+type gitSnapshot struct {
+	Hash string `json:"hash"`
+}
+
+// ScanOnce 扫描一次并与snapshotPath中保存的快照比较，返回变化事件并写回新的快照
+// 快照不存在时只记录基线，不产生事件
+func (w *FileWatcher) ScanOnce(snapshotPath string) ([]Event, error) {
+	var lastState map[string]FileState
+	found, err := readSnapshot(snapshotPath, &lastState)
+	if err != nil {
+		return nil, err
+	}
+
+	currentState, err := scanPath(w.path)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []Event
+	if found {
+		eventCh := make(chan Event, len(currentState)+len(lastState))
+		compareStates(currentState, lastState, eventCh, w.filter)
+		close(eventCh)
+		for ev := range eventCh {
+			events = append(events, ev)
+		}
+	}
+
+	w.mu.Lock()
+	w.lastState = currentState
+	w.mu.Unlock()
+
+	if err := writeSnapshot(snapshotPath, currentState); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// ScanOnce 检查一次远程分支，与snapshotPath中记录的提交比较，有更新时拉取并返回Modified事件
+// 快照不存在时只记录基线，不产生事件
+func (w *GitWatcher) ScanOnce(snapshotPath string) ([]Event, error) {
+	var last gitSnapshot
+	found, err := readSnapshot(snapshotPath, &last)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := w.initRepo(); err != nil {
+		return nil, err
+	}
+
+	var hash string
+	err = w.withRetry(func() (err error) {
+		hash, err = w.getRemoteHash()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var events []Event
+	if found && hash != last.Hash {
+		if err := w.withRetry(w.pullChanges); err != nil {
+			return nil, err
+		}
+		ev := Event{
+			Type:      Modified,
+			Object:    "Git repository updated",
+			Timestamp: time.Now(),
+		}
+		if w.filter == nil || w.filter(ev) {
+			events = append(events, ev)
+		}
+	}
+	w.lastHash = hash
+
+	if err := writeSnapshot(snapshotPath, gitSnapshot{Hash: hash}); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// readSnapshot 读取JSON快照，文件不存在时返回false
+func readSnapshot(path string, v interface{}) (bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to read snapshot %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return false, fmt.Errorf("failed to parse snapshot %s: %w", path, err)
+	}
+	return true, nil
+}
+
+// writeSnapshot 先写临时文件再重命名，避免中断时留下不完整的快照
+func writeSnapshot(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("failed to write snapshot %s: %w", path, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write snapshot %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write snapshot %s: %w", path, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write snapshot %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package watcher
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// resourceCounters 单个资源的事件计数，在读锁下通过原子操作更新
+type resourceCounters struct {
+	events  atomic.Uint64
+	dropped atomic.Uint64
+	evicted atomic.Uint64
+	// handlerDropped 异步处理器队列已满而丢弃的事件数
+	handlerDropped atomic.Uint64
+}
+
+// ResourceStats 单个资源的运行统计
+type ResourceStats struct {
+	Key             ResourceKey `json:"key"`
+	Clients         int         `json:"clients"`
+	Events          uint64      `json:"events"`
+	EventsPerSecond float64     `json:"eventsPerSecond"`
+	Dropped         uint64      `json:"dropped"`
+	Evicted         uint64      `json:"evicted"`
+	HandlerDropped  uint64      `json:"handlerDropped"`
+	Stored          int         `json:"stored"`
+}
+
+// WatchServerStats WatchServer的运行统计
+type WatchServerStats struct {
+	StartedAt time.Time       `json:"startedAt"`
+	Uptime    string          `json:"uptime"`
+	Watchers  int             `json:"watchers"`
+	Clients   int             `json:"clients"`
+	Resources []ResourceStats `json:"resources"`
+}
+
+// Stats 在读锁下汇总已注册监控器、订阅者、事件吞吐、丢弃数和EventStore占用
+func (s *WatchServer) Stats() WatchServerStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	uptime := time.Since(s.startedAt)
+	stats := WatchServerStats{
+		StartedAt: s.startedAt,
+		Uptime:    uptime.Round(time.Second).String(),
+		Watchers:  len(s.watchers),
+		Resources: make([]ResourceStats, 0, len(s.watchers)),
+	}
+
+	for key := range s.watchers {
+		rs := ResourceStats{
+			Key:     key,
+			Clients: len(s.clients[key]),
+			Stored:  s.eventStore.Len(key),
+		}
+		if c := s.counters[key]; c != nil {
+			rs.Events = c.events.Load()
+			rs.Dropped = c.dropped.Load()
+			rs.Evicted = c.evicted.Load()
+			rs.HandlerDropped = c.handlerDropped.Load()
+		}
+		if secs := uptime.Seconds(); secs > 0 {
+			rs.EventsPerSecond = float64(rs.Events) / secs
+		}
+		stats.Clients += rs.Clients
+		stats.Resources = append(stats.Resources, rs)
+	}
+
+	sort.Slice(stats.Resources, func(i, j int) bool {
+		a, b := stats.Resources[i].Key, stats.Resources[j].Key
+		if a.Resource != b.Resource {
+			return a.Resource < b.Resource
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+	return stats
+}
+
+// StatsHandler 返回以JSON输出Stats的HTTP处理器，通常挂载在/stats
+func (s *WatchServer) StatsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(s.Stats())
+	})
+}
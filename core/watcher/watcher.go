@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -19,6 +20,13 @@ type Watcher interface {
 	List() ([]interface{}, error)
 }
 
+// Recoverable 可选接口：实现该接口的监控器在事件通道意外关闭或Watch失败后，
+// 由WatchServer按指数退避重新调用Watch
+type Recoverable interface {
+	// Recoverable 返回监控器当前是否允许重连（已Stop的监控器应返回false）
+	Recoverable() bool
+}
+
 // ResourceKey 资源标识符
 type ResourceKey struct {
 	Group     string
@@ -31,24 +39,86 @@ type ResourceKey struct {
 // EventHandler 处理事件的函数类型
 type EventHandler func(event Event)
 
+// EventFilter 事件过滤函数，返回false的事件不会发送到事件通道
+// Error事件不经过过滤，避免掩盖监控器故障
+type EventFilter func(event Event) bool
+
+// sendEvent 经过滤后发送事件，filter为nil时全部发送
+func sendEvent(eventCh chan<- Event, filter EventFilter, event Event) {
+	if filter != nil && event.Type != Error && !filter(event) {
+		return
+	}
+	eventCh <- event
+}
+
 // ================== 事件分发系统 ==================
 
+// 监控器重连的默认退避参数
+const (
+	defaultRetryBase = time.Second
+	defaultRetryMax  = time.Minute
+)
+
+// defaultMaxDrops 订阅者连续丢弃事件超过该值后被自动驱逐
+const defaultMaxDrops = 100
+
+// watchClient 订阅者通道及其连续丢弃计数
+type watchClient struct {
+	ch    chan Event
+	drops atomic.Int64
+}
+
 // WatchServer 事件分发服务器
 type WatchServer struct {
 	mu         sync.RWMutex
 	watchers   map[ResourceKey]Watcher
-	clients    map[ResourceKey]map[uint64]chan Event
+	clients    map[ResourceKey]map[uint64]*watchClient
 	nextClient uint64
 	eventStore *EventStore
+	retryBase  time.Duration // 首次重连等待时间
+	retryMax   time.Duration // 重连等待时间上限
+	maxDrops   int64         // 连续丢弃阈值，超过后驱逐订阅者
+	counters   map[ResourceKey]*resourceCounters
+	handlers   map[ResourceKey][]*registeredHandler
+	startedAt  time.Time
+
+	// ctx在Stop时取消，结束所有监控协程
+	ctx     context.Context
+	cancel  context.CancelFunc
+	workers sync.WaitGroup // 异步事件处理器的协程
 }
 
 // NewWatchServer 创建新的Watch服务器
 func NewWatchServer() *WatchServer {
+	ctx, cancel := context.WithCancel(context.Background())
 	return &WatchServer{
 		watchers:   make(map[ResourceKey]Watcher),
-		clients:    make(map[ResourceKey]map[uint64]chan Event),
+		clients:    make(map[ResourceKey]map[uint64]*watchClient),
 		eventStore: NewEventStore(1000), // 存储最近的1000个事件
+		retryBase:  defaultRetryBase,
+		retryMax:   defaultRetryMax,
+		maxDrops:   defaultMaxDrops,
+		counters:   make(map[ResourceKey]*resourceCounters),
+		handlers:   make(map[ResourceKey][]*registeredHandler),
+		startedAt:  time.Now(),
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+}
+
+// Stop 结束所有监控协程，正在等待重连的监控器不再重试，并等待异步处理器处理完已入队的事件；
+// 监控器本身由调用方Stop
+func (s *WatchServer) Stop() {
+	s.mu.Lock()
+	if s.ctx.Err() != nil {
+		s.mu.Unlock()
+		return
 	}
+	s.cancel()
+	s.closeHandlers()
+	s.mu.Unlock()
+
+	s.workers.Wait()
 }
 
 // RegisterWatcher 注册资源监控器
@@ -61,7 +131,8 @@ func (s *WatchServer) RegisterWatcher(key ResourceKey, watcher Watcher) error {
 	}
 
 	s.watchers[key] = watcher
-	s.clients[key] = make(map[uint64]chan Event)
+	s.clients[key] = make(map[uint64]*watchClient)
+	s.counters[key] = &resourceCounters{}
 
 	// 启动监控协程
 	go s.startWatching(key, watcher)
@@ -85,7 +156,7 @@ func (s *WatchServer) Watch(key ResourceKey, resourceVersion string) (<-chan Eve
 
 	// 创建事件通道
 	eventCh := make(chan Event, 100)
-	s.clients[key][clientID] = eventCh
+	s.clients[key][clientID] = &watchClient{ch: eventCh}
 
 	// 如果提供了resourceVersion，发送历史事件
 	if resourceVersion != "" {
@@ -104,24 +175,58 @@ func (s *WatchServer) Unwatch(key ResourceKey, clientID uint64) {
 	defer s.mu.Unlock()
 
 	if clients, ok := s.clients[key]; ok {
-		if ch, exists := clients[clientID]; exists {
-			close(ch)
+		if c, exists := clients[clientID]; exists {
+			close(c.ch)
 			delete(clients, clientID)
 		}
 	}
 }
 
 func (s *WatchServer) startWatching(key ResourceKey, watcher Watcher) {
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	ctx := s.ctx
+
+	backoff := s.retryBase
+	for {
+		eventCh, err := watcher.Watch(ctx)
+		if err == nil {
+			if s.dispatchEvents(key, eventCh) > 0 {
+				// 成功恢复并产生过事件，重置退避时间
+				backoff = s.retryBase
+			}
+			err = fmt.Errorf("event channel closed")
+		}
+		if ctx.Err() != nil {
+			return
+		}
 
-	eventCh, err := watcher.Watch(ctx)
-	if err != nil {
-		// 处理错误
-		return
+		// 不可恢复的监控器保持原有行为：停止分发
+		if r, ok := watcher.(Recoverable); !ok || !r.Recoverable() {
+			return
+		}
+
+		s.broadcast(key, Event{
+			Type:      Error,
+			Object:    fmt.Sprintf("watcher for %v failed: %v, retrying in %s", key, err, backoff),
+			Timestamp: time.Now(),
+		})
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > s.retryMax {
+			backoff = s.retryMax
+		}
 	}
+}
 
+// dispatchEvents 存储并分发事件直到通道关闭，返回分发的事件数
+func (s *WatchServer) dispatchEvents(key ResourceKey, eventCh <-chan Event) int {
+	count := 0
 	for event := range eventCh {
+		raw := event
 		s.mu.RLock()
 
 		// 存储事件
@@ -129,15 +234,85 @@ func (s *WatchServer) startWatching(key ResourceKey, watcher Watcher) {
 		event.Object = s.addResourceVersion(event.Object, resourceVersion)
 
 		// 分发事件给所有订阅者
-		for _, clientCh := range s.clients[key] {
-			select {
-			case clientCh <- event:
-			default:
-				// 避免阻塞，跳过事件
-			}
+		slow := s.broadcastLocked(key, event)
+		if c := s.counters[key]; c != nil {
+			c.events.Add(1)
 		}
 
 		s.mu.RUnlock()
+		s.evict(key, slow)
+
+		// 服务端处理器收到原始事件
+		s.dispatchHandlers(key, raw)
+		count++
+	}
+	return count
+}
+
+// broadcast 将事件（不存储）发送给所有订阅者
+func (s *WatchServer) broadcast(key ResourceKey, event Event) {
+	s.mu.RLock()
+	slow := s.broadcastLocked(key, event)
+	s.mu.RUnlock()
+
+	s.evict(key, slow)
+}
+
+// broadcastLocked 调用方需持有s.mu，返回连续丢弃超过阈值、需要驱逐的订阅者
+func (s *WatchServer) broadcastLocked(key ResourceKey, event Event) []uint64 {
+	var slow []uint64
+	for clientID, client := range s.clients[key] {
+		select {
+		case client.ch <- event:
+			client.drops.Store(0)
+		default:
+			// 避免阻塞，跳过事件
+			if c := s.counters[key]; c != nil {
+				c.dropped.Add(1)
+			}
+			if s.maxDrops > 0 && client.drops.Add(1) >= s.maxDrops {
+				slow = append(slow, clientID)
+			}
+		}
+	}
+	return slow
+}
+
+// evict 向落后过多的订阅者发送最终Error事件后关闭其通道并取消订阅，
+// 客户端可携带最后收到的resourceVersion重新Watch以补齐事件
+func (s *WatchServer) evict(key ResourceKey, clientIDs []uint64) {
+	if len(clientIDs) == 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, clientID := range clientIDs {
+		client, exists := s.clients[key][clientID]
+		if !exists {
+			continue
+		}
+
+		// 通道已满，丢弃最旧的一个事件为最终通知腾出空间
+		select {
+		case <-client.ch:
+		default:
+		}
+		select {
+		case client.ch <- Event{
+			Type:      Error,
+			Object:    fmt.Sprintf("client %d too far behind: %d consecutive events dropped", clientID, client.drops.Load()),
+			Timestamp: time.Now(),
+		}:
+		default:
+		}
+
+		close(client.ch)
+		delete(s.clients[key], clientID)
+		if c := s.counters[key]; c != nil {
+			c.evicted.Add(1)
+		}
 	}
 }
 
@@ -2,8 +2,16 @@ package watcher
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -221,6 +229,101 @@ func TestFileWatcherList(t *testing.T) {
 	}
 }
 
+func TestFileWatcherDuplicates(t *testing.T) {
+	dir := t.TempDir()
+
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("same"), 0644)
+	os.WriteFile(filepath.Join(dir, "b.txt"), []byte("same"), 0644)
+	os.WriteFile(filepath.Join(dir, "c.txt"), []byte("other"), 0644)
+
+	fw := NewFileWatcher(dir, time.Second)
+	if err := fw.scanFiles(); err != nil {
+		t.Fatalf("scanFiles: %v", err)
+	}
+
+	dups := fw.Duplicates()
+	if len(dups) != 1 {
+		t.Fatalf("expected 1 duplicate group, got %d: %v", len(dups), dups)
+	}
+	for _, paths := range dups {
+		want := []string{filepath.Join(dir, "a.txt"), filepath.Join(dir, "b.txt")}
+		if len(paths) != 2 || paths[0] != want[0] || paths[1] != want[1] {
+			t.Errorf("expected %v, got %v", want, paths)
+		}
+	}
+}
+
+func TestFileWatcherFilter(t *testing.T) {
+	dir := t.TempDir()
+
+	fw := NewFileWatcher(dir, time.Second)
+	fw.SetFilter(func(ev Event) bool {
+		path, _ := ev.Object.(string)
+		return strings.HasSuffix(path, ".go")
+	})
+	if err := fw.scanFiles(); err != nil {
+		t.Fatalf("scanFiles: %v", err)
+	}
+
+	os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main"), 0644)
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("notes"), 0644)
+
+	eventCh := make(chan Event, 10)
+	fw.detectChanges(eventCh)
+	close(eventCh)
+
+	var got []Event
+	for ev := range eventCh {
+		got = append(got, ev)
+	}
+	if len(got) != 1 || got[0].Object != filepath.Join(dir, "main.go") {
+		t.Fatalf("expected only main.go, got %v", got)
+	}
+}
+
+func TestFileWatcherScanOnce(t *testing.T) {
+	dir := t.TempDir()
+	snapshot := filepath.Join(t.TempDir(), "snapshot.json")
+
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a"), 0644)
+	os.WriteFile(filepath.Join(dir, "b.txt"), []byte("b"), 0644)
+
+	fw := NewFileWatcher(dir, time.Second)
+
+	// First run only records the baseline
+	events, err := fw.ScanOnce(snapshot)
+	if err != nil {
+		t.Fatalf("ScanOnce: %v", err)
+	}
+	if len(events) != 0 {
+		t.Fatalf("expected no events on first run, got %v", events)
+	}
+
+	os.WriteFile(filepath.Join(dir, "c.txt"), []byte("c"), 0644)
+	os.Remove(filepath.Join(dir, "b.txt"))
+
+	events, err = fw.ScanOnce(snapshot)
+	if err != nil {
+		t.Fatalf("ScanOnce: %v", err)
+	}
+	got := map[EventType]string{}
+	for _, ev := range events {
+		got[ev.Type] = ev.Object.(string)
+	}
+	if len(events) != 2 || got[Added] != filepath.Join(dir, "c.txt") || got[Deleted] != filepath.Join(dir, "b.txt") {
+		t.Fatalf("unexpected events: %v", events)
+	}
+
+	// Nothing changed since the last snapshot
+	events, err = fw.ScanOnce(snapshot)
+	if err != nil {
+		t.Fatalf("ScanOnce: %v", err)
+	}
+	if len(events) != 0 {
+		t.Fatalf("expected no events, got %v", events)
+	}
+}
+
 func TestWatchServerLifecycle(t *testing.T) {
 	dir := t.TempDir()
 	os.WriteFile(filepath.Join(dir, "init.txt"), []byte("init"), 0644)
@@ -254,6 +357,348 @@ func TestWatchServerLifecycle(t *testing.T) {
 	}
 }
 
+// flakyWatcher fails the first `failures` Watch calls, then emits one event and closes the channel
+type flakyWatcher struct {
+	mu       sync.Mutex
+	failures int
+	calls    int
+	stopped  bool
+}
+
+func (w *flakyWatcher) Watch(ctx context.Context) (<-chan Event, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.calls++
+	if w.calls <= w.failures {
+		return nil, errors.New("source unavailable")
+	}
+	ch := make(chan Event, 1)
+	ch <- Event{Type: Modified, Object: w.calls, Timestamp: time.Now()}
+	close(ch)
+	return ch, nil
+}
+
+func (w *flakyWatcher) Stop() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.stopped = true
+}
+
+func (w *flakyWatcher) List() ([]interface{}, error) { return nil, nil }
+
+func (w *flakyWatcher) Recoverable() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return !w.stopped
+}
+
+func TestWatchServerReconnectsRecoverableWatcher(t *testing.T) {
+	server := NewWatchServer()
+	server.retryBase = 10 * time.Millisecond
+	server.retryMax = 40 * time.Millisecond
+	key := resourceKey("flaky")
+	fw := &flakyWatcher{failures: 3}
+	defer fw.Stop()
+
+	if err := server.RegisterWatcher(key, fw); err != nil {
+		t.Fatalf("RegisterWatcher: %v", err)
+	}
+	ch, clientID, err := server.Watch(key, "")
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+	defer server.Unwatch(key, clientID)
+
+	errorsSeen := 0
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case ev := <-ch:
+			if ev.Type == Error {
+				errorsSeen++
+				continue
+			}
+			if ev.Type != Modified {
+				continue
+			}
+			if errorsSeen == 0 {
+				t.Fatal("expected Error events before recovery")
+			}
+			return
+		case <-timeout:
+			t.Fatalf("timeout waiting for recovery, got %d Error events", errorsSeen)
+		}
+	}
+}
+
+// removing the watched root (e.g. a lost NFS mount) closes the event channel,
+// the server retries until the root is back and then resumes dispatching
+func TestWatchServerReconnectsRemovedRoot(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "root")
+	if err := os.Mkdir(root, 0755); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(root, "init.txt"), []byte("init"), 0644)
+
+	server := NewWatchServer()
+	defer server.Stop()
+	server.retryBase = 10 * time.Millisecond
+	server.retryMax = 40 * time.Millisecond
+	key := resourceKey("removed-root")
+	fw := NewFileWatcher(root, 20*time.Millisecond)
+	defer fw.Stop()
+	if err := server.RegisterWatcher(key, fw); err != nil {
+		t.Fatalf("RegisterWatcher: %v", err)
+	}
+	ch, clientID, err := server.Watch(key, "")
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+	defer server.Unwatch(key, clientID)
+	timeout := time.After(3 * time.Second)
+
+	// keeps adding files until the watch loop reports one of them
+	waitForNewFile := func(prefix string) {
+		t.Helper()
+		tick := time.NewTicker(50 * time.Millisecond)
+		defer tick.Stop()
+		for n := 0; ; {
+			select {
+			case <-tick.C:
+				n++
+				os.WriteFile(filepath.Join(root, fmt.Sprintf("%s-%d.txt", prefix, n)), []byte("x"), 0644)
+			case ev := <-ch:
+				if ev.Type != Added {
+					continue
+				}
+				if path, _ := extractObject(t, ev.Object).(string); strings.HasPrefix(filepath.Base(path), prefix+"-") {
+					return
+				}
+			case <-timeout:
+				t.Fatalf("timeout waiting for %s files", prefix)
+			}
+		}
+	}
+
+	waitForNewFile("before")
+	if err := os.RemoveAll(root); err != nil {
+		t.Fatal(err)
+	}
+	for retrying := false; !retrying; {
+		select {
+		case ev := <-ch:
+			retrying = ev.Type == Error && strings.Contains(fmt.Sprint(ev.Object), "retrying")
+		case <-timeout:
+			t.Fatal("timeout waiting for the server to retry")
+		}
+	}
+
+	if err := os.Mkdir(root, 0755); err != nil {
+		t.Fatal(err)
+	}
+	waitForNewFile("after")
+}
+
+func TestWatchServerStopEndsRetries(t *testing.T) {
+	server := NewWatchServer()
+	server.retryBase = time.Hour
+	server.retryMax = time.Hour
+	key := resourceKey("stopped")
+	fw := &flakyWatcher{failures: 1}
+	done := make(chan struct{})
+	server.watchers[key] = fw
+	server.clients[key] = make(map[uint64]*watchClient)
+	server.counters[key] = &resourceCounters{}
+	go func() {
+		server.startWatching(key, fw)
+		close(done)
+	}()
+
+	// the first Watch fails, the server waits for the backoff until stopped
+	time.Sleep(50 * time.Millisecond)
+	server.Stop()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Stop did not interrupt the retry backoff")
+	}
+}
+
+func TestWatchServerStats(t *testing.T) {
+	dir := t.TempDir()
+	server := NewWatchServer()
+	key := resourceKey("stats")
+	if err := server.RegisterWatcher(key, NewFileWatcher(dir, 50*time.Millisecond)); err != nil {
+		t.Fatalf("RegisterWatcher: %v", err)
+	}
+	ch, clientID, err := server.Watch(key, "")
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+	defer server.Unwatch(key, clientID)
+
+	// Keep changing the file until the watcher has dispatched at least one event
+	deadline := time.After(2 * time.Second)
+	for i := 1; server.Stats().Resources[0].Events == 0; i++ {
+		os.WriteFile(filepath.Join(dir, "a.txt"), make([]byte, i), 0644)
+		select {
+		case <-ch:
+		case <-time.After(60 * time.Millisecond):
+		case <-deadline:
+			t.Fatal("timeout waiting for dispatched event")
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	server.StatsHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stats", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	var stats WatchServerStats
+	if err := json.Unmarshal(rec.Body.Bytes(), &stats); err != nil {
+		t.Fatalf("decode stats: %v", err)
+	}
+	if stats.Watchers != 1 || len(stats.Resources) != 1 {
+		t.Fatalf("expected 1 watcher, got %+v", stats)
+	}
+	rs := stats.Resources[0]
+	if rs.Clients != 1 || rs.Events < 1 || rs.Stored < 1 {
+		t.Errorf("unexpected resource stats: %+v", rs)
+	}
+}
+
+func TestWatchServerEvictsSlowClient(t *testing.T) {
+	server := NewWatchServer()
+	server.maxDrops = 3
+	key := resourceKey("slow")
+	server.watchers[key] = &flakyWatcher{}
+	server.clients[key] = make(map[uint64]*watchClient)
+	server.counters[key] = &resourceCounters{}
+
+	ch, clientID, err := server.Watch(key, "")
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+
+	// Never read from ch: fill the buffer, then exceed the drop threshold
+	for i := 0; i < cap(ch)+int(server.maxDrops); i++ {
+		server.broadcast(key, Event{Type: Modified, Object: i})
+	}
+
+	server.mu.RLock()
+	_, stillSubscribed := server.clients[key][clientID]
+	server.mu.RUnlock()
+	if stillSubscribed {
+		t.Fatal("expected slow client to be unwatched")
+	}
+
+	var last Event
+	for ev := range ch {
+		last = ev
+	}
+	if last.Type != Error {
+		t.Errorf("expected final Error event, got %s", last.Type)
+	}
+	if got := server.Stats().Resources[0].Evicted; got != 1 {
+		t.Errorf("expected 1 evicted client, got %d", got)
+	}
+}
+
+func TestWatchServerHandlers(t *testing.T) {
+	server := NewWatchServer()
+	key := resourceKey("handlers")
+	server.watchers[key] = &flakyWatcher{}
+	server.clients[key] = make(map[uint64]*watchClient)
+	server.counters[key] = &resourceCounters{}
+
+	var got []Event
+	if err := server.AddHandler(key, func(ev Event) { got = append(got, ev) }); err != nil {
+		t.Fatalf("AddHandler: %v", err)
+	}
+	panicked := make(chan struct{})
+	if err := server.AddHandlerPool(key, func(ev Event) {
+		defer close(panicked)
+		panic("boom")
+	}, 2); err != nil {
+		t.Fatalf("AddHandlerPool: %v", err)
+	}
+	if err := server.AddHandler(resourceKey("missing"), func(Event) {}); err == nil {
+		t.Error("expected error for unregistered resource")
+	}
+
+	ch, _, err := server.Watch(key, "")
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+
+	eventCh := make(chan Event, 1)
+	eventCh <- Event{Type: Modified, Object: "file.txt", Timestamp: time.Now()}
+	close(eventCh)
+	server.dispatchEvents(key, eventCh)
+
+	// Synchronous handlers run before dispatchEvents returns and see the raw object
+	if len(got) != 1 || got[0].Object != "file.txt" {
+		t.Fatalf("expected raw event in sync handler, got %v", got)
+	}
+
+	<-panicked
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case ev := <-ch:
+			if ev.Type == Error && strings.Contains(ev.Object.(string), "panicked") {
+				return
+			}
+		case <-timeout:
+			t.Fatal("expected Error event for handler panic")
+		}
+	}
+}
+
+func TestWatchServerStopDrainsHandlerPools(t *testing.T) {
+	server := NewWatchServer()
+	key := resourceKey("pool")
+	server.watchers[key] = &flakyWatcher{}
+	server.clients[key] = make(map[uint64]*watchClient)
+	server.counters[key] = &resourceCounters{}
+
+	var handled atomic.Int64
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	if err := server.AddHandlerPool(key, func(Event) {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-release
+		handled.Add(1)
+	}, 1); err != nil {
+		t.Fatalf("AddHandlerPool: %v", err)
+	}
+
+	// the only worker is busy: fill the queue, further events are dropped without blocking
+	server.dispatchHandlers(key, Event{Type: Modified})
+	<-started
+	for i := 0; i < defaultHandlerQueue+3; i++ {
+		server.dispatchHandlers(key, Event{Type: Modified})
+	}
+	if got := server.Stats().Resources[0].HandlerDropped; got != 3 {
+		t.Errorf("HandlerDropped = %d, want 3", got)
+	}
+
+	close(release)
+	server.Stop()
+	if got := handled.Load(); got != defaultHandlerQueue+1 {
+		t.Errorf("handled %d events before Stop returned, want %d", got, defaultHandlerQueue+1)
+	}
+
+	// dispatching after Stop is a no-op instead of a send on a closed queue
+	server.dispatchHandlers(key, Event{Type: Modified})
+	if err := server.AddHandlerPool(key, func(Event) {}, 1); err == nil {
+		t.Error("expected error adding a handler pool after Stop")
+	}
+}
+
 // helpers
 
 func extractObject(t *testing.T, obj interface{}) interface{} {
@@ -281,3 +726,48 @@ func checkHasVersion(t *testing.T, obj interface{}, expectedVersion string) {
 		t.Fatalf("Object is not versioned: %T", obj)
 	}
 }
+
+func TestBatchQuietPeriod(t *testing.T) {
+	in := make(chan Event)
+	out := Batch(context.Background(), in, 50*time.Millisecond, 0)
+
+	for i := 0; i < 5; i++ {
+		in <- Event{Type: Modified, Object: i}
+	}
+
+	select {
+	case batch := <-out:
+		if len(batch) != 5 {
+			t.Fatalf("expected 5 events in batch, got %d", len(batch))
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected a batch after the quiet period")
+	}
+
+	in <- Event{Type: Added, Object: "last"}
+	close(in)
+	if batch := <-out; len(batch) != 1 || batch[0].Object != "last" {
+		t.Fatalf("expected remaining event on close, got %v", batch)
+	}
+	if _, ok := <-out; ok {
+		t.Fatal("expected output channel to be closed")
+	}
+}
+
+func TestBatchMaxSize(t *testing.T) {
+	in := make(chan Event, 10)
+	out := Batch(context.Background(), in, time.Hour, 3)
+
+	for i := 0; i < 7; i++ {
+		in <- Event{Type: Modified, Object: i}
+	}
+	close(in)
+
+	var sizes []int
+	for batch := range out {
+		sizes = append(sizes, len(batch))
+	}
+	if len(sizes) != 3 || sizes[0] != 3 || sizes[1] != 3 || sizes[2] != 1 {
+		t.Fatalf("expected batches of 3,3,1, got %v", sizes)
+	}
+}
@@ -0,0 +1,69 @@
+package installer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// cachePath returns the cache file for a query, keyed by user/program/release
+// plus a hash of the options that change which assets are resolved
+func (o InstallOptions) cachePath(q Query) (string, error) {
+	dir, err := os.UserCacheDir() // honors XDG_CACHE_HOME
+	if err != nil {
+		return "", err
+	}
+	opts := fmt.Sprintf("%s|%s|%t|%s|%s|%s", q.Select, q.OS, q.Prerelease, q.Libc, o.Source, o.GitlabURL)
+	sum := sha256.Sum256([]byte(opts))
+	name := url.PathEscape(q.User+"/"+q.Program+"@"+q.Release) + "-" + hex.EncodeToString(sum[:4]) + ".json"
+	return filepath.Join(dir, "myUtilities", "installer", name), nil
+}
+
+// readCache returns the cached result when it is younger than the TTL,
+// fields that only affect the script are taken from q
+func (o InstallOptions) readCache(q Query) (QueryResult, bool) {
+	path, err := o.cachePath(q)
+	if err != nil {
+		return QueryResult{}, false
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return QueryResult{}, false
+	}
+	var r QueryResult
+	if err := json.Unmarshal(b, &r); err != nil || time.Since(r.Timestamp) >= o.CacheTTL {
+		return QueryResult{}, false
+	}
+	// the search fallback may have resolved a different user/program
+	user, program, release, search := r.User, r.Program, r.Release, r.Search
+	r.Query = q
+	r.User, r.Program, r.Release, r.Search = user, program, release, search
+	return r, true
+}
+
+// writeCache stores a query result, errors are ignored as the cache is optional
+func (o InstallOptions) writeCache(q Query, r QueryResult) {
+	path, err := o.cachePath(q)
+	if err != nil {
+		return
+	}
+	b, err := json.Marshal(r)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+	}
+}
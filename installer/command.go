@@ -3,20 +3,37 @@ package installer
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/yusiwen/myUtilities/installer/templates"
 	"io"
 	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
 	"sort"
+	"strconv"
 	"strings"
+	"text/tabwriter"
 	"text/template"
 	"time"
+
+	"golang.org/x/sync/errgroup"
 )
 
 var (
 	errNotFound = errors.New("not found")
+	// errNoSumFile is returned when a release publishes no checksum file
+	errNoSumFile = errors.New("no sum file found")
+	// errRateLimited is returned when the GitHub API quota is used up
+	errRateLimited = errors.New("GitHub API rate limit exceeded")
+	// githubAPI is the GitHub REST endpoint, variable so tests can point it at a local server
+	githubAPI = "https://api.github.com"
+	// retryBase is the first backoff delay of get, doubled on each retry
+	retryBase = time.Second
 )
 
 type Asset struct {
@@ -24,9 +41,18 @@ type Asset struct {
 }
 
 func (a Asset) Key() string {
+	if a.IsPackage() {
+		// packages don't compete with binaries, the script picks between them
+		return a.OS + "/" + a.Arch + "/" + strings.TrimPrefix(a.Type, ".")
+	}
 	return a.OS + "/" + a.Arch
 }
 
+// IsPackage reports whether the asset is a native .deb/.rpm package
+func (a Asset) IsPackage() bool {
+	return a.Type == ".deb" || a.Type == ".rpm"
+}
+
 func (a Asset) Is32Bit() bool {
 	return a.Arch == "386"
 }
@@ -51,37 +77,198 @@ func (as Assets) HasM1() bool {
 	return false
 }
 
-func (o Options) get(url string, v interface{}) error {
-	req, _ := http.NewRequest("GET", url, nil)
-	req.Header.Set("Accept", "application/vnd.github.v3+json")
-	if o.Token != "" {
-		req.Header.Set("Authorization", "token "+o.Token)
+// get fetches url as JSON into v, retrying network errors and 5xx responses
+// up to o.Retries times with exponential backoff
+func (o InstallOptions) get(ctx context.Context, url string, v interface{}) error {
+	backoff := retryBase
+	for attempt := 0; ; attempt++ {
+		retry, err := o.getOnce(ctx, url, v)
+		if err == nil || !retry || attempt >= o.Retries {
+			return err
+		}
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return err
+		}
+		backoff *= 2
+	}
+}
+
+// getOnce performs a single request, retry reports whether the failure is transient
+func (o InstallOptions) getOnce(ctx context.Context, url string, v interface{}) (retry bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return false, err
+	}
+	if o.Source != "gitlab" {
+		req.Header.Set("Accept", "application/vnd.github.v3+json")
 	}
-	resp, err := http.Get(url)
+	o.setToken(req)
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("request failed: %s: %s", url, err)
+		return ctx.Err() == nil, fmt.Errorf("request failed: %s: %s", url, err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode == 404 {
-		return fmt.Errorf("%w: url %s", errNotFound, url)
+		return false, fmt.Errorf("%w: url %s", errNotFound, url)
+	}
+	if err := rateLimitError(resp); err != nil {
+		return false, err
 	}
 	if resp.StatusCode != 200 {
 		b, _ := io.ReadAll(resp.Body)
-		return errors.New(http.StatusText(resp.StatusCode) + " " + string(b))
+		return resp.StatusCode >= 500, errors.New(http.StatusText(resp.StatusCode) + " " + string(b))
 	}
 	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
-		return fmt.Errorf("download failed: %s: %s", url, err)
+		return false, fmt.Errorf("download failed: %s: %s", url, err)
+	}
+	return false, nil
+}
+
+// rateLimitError reports an exhausted GitHub quota (403/429 with
+// X-RateLimit-Remaining: 0) as errRateLimited, including when it resets
+func rateLimitError(resp *http.Response) error {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return nil
+	}
+	if resp.Header.Get("X-RateLimit-Remaining") != "0" {
+		return nil
+	}
+	reset := "later"
+	if sec, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		t := time.Unix(sec, 0)
+		reset = fmt.Sprintf("at %s (in %s)", t.Format(time.Kitchen), time.Until(t).Round(time.Second))
+	}
+	return fmt.Errorf("%w, it resets %s; authenticated requests get a higher limit, pass --token or set GITHUB_TOKEN", errRateLimited, reset)
+}
+
+// searcher returns the search used when the repository was given without
+// user, nil disables the fallback
+func (o InstallOptions) searcher() Searcher {
+	switch {
+	case o.NoSearch || o.Source == "gitlab":
+		return nil
+	case o.Searcher != nil:
+		return o.Searcher
+	case o.SearchWith == "github":
+		return GitHubSearcher{Token: o.Token}
+	}
+	return WebSearcher
+}
+
+// setToken adds the --token credential in the form the release source expects,
+// only for hosts belonging to that source so mirrors never receive it
+func (o InstallOptions) setToken(req *http.Request) {
+	if o.Token == "" {
+		return
+	}
+	if o.Source == "gitlab" {
+		if u, err := url.Parse(o.gitlabBase()); err == nil && u.Host == req.URL.Host && req.Header.Get("PRIVATE-TOKEN") == "" {
+			req.Header.Set("PRIVATE-TOKEN", o.Token)
+		}
+		return
+	}
+	api, _ := url.Parse(githubAPI)
+	host := req.URL.Host
+	if (api != nil && api.Host == host) || host == "github.com" {
+		if req.Header.Get("Authorization") == "" {
+			req.Header.Set("Authorization", "token "+o.Token)
+		}
 	}
-	return nil
 }
 
 type Query struct {
 	User, Program, Release       string
 	AsProgram, Select            string
 	MoveToPath, Search, Insecure bool
-	SudoMove                     bool   // deprecated: not used, now automatically detected
-	OS, Arch                     string // override OS and Arch
+	SudoMove                     bool     // deprecated: not used, now automatically detected
+	OS, Arch                     string   // override OS and Arch
+	BinDir                       string   // move target when MoveToPath is set
+	DownloadHeaders              []string // extra "Key: Value" headers for asset downloads
+	Prerelease                   bool     // let "latest" resolve to prereleases too
+	Libc                         string   // preferred linux libc, "musl" or "gnu"
+}
+
+// resolveLibc turns the --libc flag into "musl" or "gnu", auto detects the
+// libc of the running host and falls back to musl (portable) elsewhere
+func resolveLibc(libc string) string {
+	if libc == "musl" || libc == "gnu" {
+		return libc
+	}
+	if runtime.GOOS == "linux" {
+		if ms, _ := filepath.Glob("/lib/ld-musl-*"); len(ms) > 0 {
+			return "musl"
+		}
+		if gs, _ := filepath.Glob("/lib*/ld-linux*"); len(gs) > 0 {
+			return "gnu"
+		}
+	}
+	return "musl"
+}
+
+// libcOf returns the libc an asset name is built for, if any
+func libcOf(name string) string {
+	name = strings.ToLower(name)
+	if strings.Contains(name, "musl") {
+		return "musl"
+	}
+	if strings.Contains(name, "gnu") {
+		return "gnu"
+	}
+	return ""
+}
+
+// baseQuery builds the query for repo from the shared release flags
+func (o ResolveOptions) baseQuery(repo string) (Query, error) {
+	headers, err := parseHeaders(o.DownloadHeader)
+	if err != nil {
+		return Query{}, err
+	}
+	q := Query{
+		Select:          o.Select,
+		OS:              o.Os,
+		Arch:            o.Arch,
+		DownloadHeaders: headers,
+		Prerelease:      o.Prerelease,
+		Libc:            resolveLibc(o.Libc),
+	}
+	q.setRepo(repo)
+	return q, nil
+}
+
+// withTimeout bounds all requests of a command, zero means no limit
+func withTimeout(d time.Duration) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), d)
+}
+
+// setRepo fills User, Program and Release from "user/program@release"
+func (q *Query) setRepo(repo string) {
+	var rest string
+	q.User, rest = splitHalf(repo, "/")
+	q.Program, q.Release = splitHalf(rest, "@")
+	// no program? treat first part as program, use default user
+	if q.Program == "" {
+		q.Program = q.User
+		q.Search = true
+	}
+	if q.Release == "" {
+		q.Release = "latest"
+	}
+}
+
+// downloadHeader returns the extra headers to send with asset and checksum downloads
+func (q Query) downloadHeader() http.Header {
+	h := http.Header{}
+	for _, kv := range q.DownloadHeaders {
+		k, v, _ := strings.Cut(kv, ":")
+		h.Add(strings.TrimSpace(k), strings.TrimSpace(v))
+	}
+	return h
 }
 
 type QueryResult struct {
@@ -92,80 +279,167 @@ type QueryResult struct {
 	M1Asset         bool
 }
 
-func (o Options) Run() error {
-	script := ""
+func (o InstallOptions) Run() error {
 	// type specific error response
 	switch o.Output {
-	case "json":
-		script = ""
-	case "shell":
-		script = string(templates.Shell)
+	case "json", "shell", "powershell":
 	default:
 		return fmt.Errorf("unknown type: %s", o.Output)
 	}
-	q := Query{
-		User:      "",
-		Program:   "",
-		Release:   "",
-		Insecure:  o.Insecure,
-		AsProgram: o.AsProgram,
-		Select:    o.Select,
-		OS:        o.Os,
-		Arch:      o.Arch,
+	q, err := o.baseQuery(o.Repo)
+	if err != nil {
+		return err
 	}
-	if o.Move {
-		q.MoveToPath = true // also allow move=1 if bang in urls cause issues
+	q.Insecure = o.Insecure
+	q.AsProgram = o.AsProgram
+	q.BinDir = o.BinDir
+	if o.Output == "powershell" {
+		// the script cannot probe the OS itself, default to the one running the installer
+		if q.OS == "" {
+			q.OS = runtime.GOOS
+		}
+		if q.OS == "windows" && (q.BinDir == "" || q.BinDir == "/usr/local/bin") {
+			q.BinDir = `~\bin`
+		}
 	}
-	var rest string
-	q.User, rest = splitHalf(o.Repo, "/")
-	q.Program, q.Release = splitHalf(rest, "@")
-	// no program? treat first part as program, use default user
-	if q.Program == "" {
-		q.Program = q.User
-		q.Search = true
+	if q.BinDir == "" {
+		q.BinDir = "/usr/local/bin"
 	}
-	if q.Release == "" {
-		q.Release = "latest"
+	if o.Move {
+		q.MoveToPath = true // also allow move=1 if bang in urls cause issues
 	}
 	// fetch assets
-	result, err := o.query(q)
+	ctx, cancel := withTimeout(o.Timeout)
+	defer cancel()
+	result, err := o.Resolve(ctx, q)
 	if err != nil {
-		return fmt.Errorf("query failed: %s", err)
+		return fmt.Errorf("query failed: %w", err)
+	}
+	// check the asset for the target platform before handing out a script
+	if o.Verify {
+		asset, err := result.pick(q.OS, q.Arch)
+		if err != nil {
+			return err
+		}
+		f, _, err := downloadVerified(ctx, asset, q.downloadHeader())
+		if err != nil {
+			return err
+		}
+		f.Close()
+		os.Remove(f.Name())
+		fmt.Fprintf(os.Stderr, "verified %s (sha256 %s)\n", asset.Name, asset.SHA256)
+	}
+	// just show what matched, nothing to render
+	if o.List {
+		result.printAssets(os.Stdout)
+		return nil
 	}
 	// no render script? just output as json
-	if script == "" {
+	if o.Output == "json" {
 		b, _ := json.MarshalIndent(result, "", "  ")
 		fmt.Printf("%s\n", b)
 		return nil
 	}
+	render := RenderShell
+	if o.Output == "powershell" {
+		render = RenderPowerShell
+	}
+	script, err := render(result)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s\n", script)
+	return nil
+}
+
+// printAssets writes the matched assets of the resolved release as a table
+func (r QueryResult) printAssets(w io.Writer) {
+	fmt.Fprintf(w, "%s/%s %s\n", r.User, r.Program, r.ResolvedRelease)
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, "OS\tARCH\tTYPE\tNAME\tSHA256")
+	for _, a := range r.Assets {
+		sum := a.SHA256
+		if sum == "" {
+			sum = "-"
+		} else if len(sum) > 12 {
+			sum = sum[:12]
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", a.OS, a.Arch, a.Type, a.Name, sum)
+	}
+	tw.Flush()
+}
+
+// Resolve looks up the release and assets for q on GitHub, using
+// GITHUB_TOKEN when set. An empty q.Release means "latest".
+func Resolve(q Query) (QueryResult, error) {
+	o := InstallOptions{ResolveOptions: ResolveOptions{Token: os.Getenv("GITHUB_TOKEN"), Source: "github", Retries: 3}}
+	return o.Resolve(context.Background(), q)
+}
+
+// Resolve is like the package level Resolve, using the source, token,
+// retries and cache settings of o
+func (o InstallOptions) Resolve(ctx context.Context, q Query) (QueryResult, error) {
+	if q.Release == "" {
+		q.Release = "latest"
+	}
+	if q.Libc == "" {
+		q.Libc = resolveLibc("auto")
+	}
+	if q.BinDir == "" {
+		q.BinDir = "/usr/local/bin"
+	}
+	return o.query(ctx, q)
+}
+
+// RenderShell returns the bash install script for a resolved release
+func RenderShell(r QueryResult) (string, error) {
+	return render(templates.Shell, r)
+}
+
+// RenderPowerShell returns the PowerShell install script for a resolved release
+func RenderPowerShell(r QueryResult) (string, error) {
+	return render(templates.PowerShell, r)
+}
+
+func render(script []byte, r QueryResult) (string, error) {
 	// load template
-	t, err := template.New("installer").Parse(script)
+	t, err := template.New("installer").Funcs(template.FuncMap{
+		"shellQuote":  shellQuote,
+		"psQuote":     psQuote,
+		"headerKey":   headerKey,
+		"headerValue": headerValue,
+	}).Parse(string(script))
 	if err != nil {
-		return fmt.Errorf("template.New() error: %s", err)
+		return "", fmt.Errorf("template.New() error: %s", err)
 	}
 	// execute template
 	buff := bytes.Buffer{}
-	if err := t.Execute(&buff, result); err != nil {
-		return fmt.Errorf("template.execute() error: %s", err)
+	if err := t.Execute(&buff, r); err != nil {
+		return "", fmt.Errorf("template.execute() error: %s", err)
 	}
-	fmt.Printf("%s\n", buff.Bytes())
-	return nil
+	return buff.String(), nil
 }
 
-func (o Options) query(q Query) (QueryResult, error) {
+func (o InstallOptions) query(ctx context.Context, q Query) (QueryResult, error) {
+	useCache, key := !o.NoCache && o.CacheTTL > 0, q
+	if useCache {
+		if result, ok := o.readCache(q); ok {
+			return result, nil
+		}
+	}
 	ts := time.Now()
-	release, assets, err := o.getAssets(q)
+	release, assets, err := o.getAssets(ctx, q)
 	if err == nil {
 		//didn't need search
 		q.Search = false
-	} else if errors.Is(err, errNotFound) && q.Search {
-		//use ddg/google to auto-detect user...
-		user, program, gerr := imFeelingLuck(q.Program)
+	} else if searcher := o.searcher(); errors.Is(err, errNotFound) && q.Search && searcher != nil {
+		//use a search engine to auto-detect user...
+		user, program, gerr := searcher.Find(ctx, q.Program)
 		if gerr == nil {
 			q.Program = program
 			q.User = user
 			//retry assets...
-			release, assets, err = o.getAssets(q)
+			release, assets, err = o.getAssets(ctx, q)
 		}
 	}
 	if err != nil {
@@ -182,96 +456,59 @@ func (o Options) query(q Query) (QueryResult, error) {
 		Assets:          assets,
 		M1Asset:         assets.HasM1(),
 	}
+	if useCache {
+		o.writeCache(key, result)
+	}
 	return result, nil
 }
 
-func (o Options) getAssets(q Query) (string, Assets, error) {
-	user := q.User
-	repo := q.Program
-	release := q.Release
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/releases", user, repo)
-	ghas := ghAssets{}
-	if release == "" || release == "latest" {
-		url += "/latest"
-		ghr := ghRelease{}
-		if err := o.get(url, &ghr); err != nil {
-			return release, nil, err
-		}
-		release = ghr.TagName //discovered
-		ghas = ghr.Assets
+func (o InstallOptions) getAssets(ctx context.Context, q Query) (string, Assets, error) {
+	var (
+		release string
+		ghas    ghAssets
+		err     error
+	)
+	if o.Source == "gitlab" {
+		release, ghas, err = o.gitlabRelease(ctx, q)
 	} else {
-		ghrs := []ghRelease{}
-		if err := o.get(url, &ghrs); err != nil {
-			return release, nil, err
-		}
-		found := false
-		for _, ghr := range ghrs {
-			if ghr.TagName == release {
-				found = true
-				if err := o.get(ghr.AssetsURL, &ghas); err != nil {
-					return release, nil, err
-				}
-				ghas = ghr.Assets
-				break
-			}
-		}
-		if !found {
-			return release, nil, fmt.Errorf("release tag '%s' not found", release)
-		}
+		release, ghas, err = o.githubRelease(ctx, q)
+	}
+	if err != nil {
+		return release, nil, err
 	}
 	if len(ghas) == 0 {
 		return release, nil, errors.New("no assets found")
 	}
-	sumIndex, _ := ghas.getSumIndex()
-	index := map[string]Asset{}
-	for _, ga := range ghas {
-		url := ga.BrowserDownloadURL
-		//only binary containers are supported
-		//TODO deb,rpm etc
-		fext := getFileExt(url)
-		if fext == "" && ga.Size > 1024*1024 {
-			fext = ".bin" // +1MB binary
-		}
-		switch fext {
-		case ".bin", ".zip", ".tar.bz", ".tar.bz2", ".bz2", ".gz", ".tar.gz", ".tgz":
-			// valid
-		default:
-			continue
-		}
-		//match
-		os := getOS(ga.Name)
-		arch := getArch(ga.Name)
-		//windows not supported yet
-		if os == "windows" {
-			//TODO: powershell
-			// EG: iwr https://deno.land/x/install/install.ps1 -useb | iex
-			continue
+	// the checksum file downloads while the assets are classified
+	var sumIndex map[string]string
+	g, gctx := errgroup.WithContext(ctx)
+	g.Go(func() error {
+		var err error
+		sumIndex, err = o.getSumIndex(gctx, ghas, q.downloadHeader())
+		if errors.Is(err, errNoSumFile) {
+			return nil
 		}
-		//unknown os, cant use
-		if os == "" {
-			continue
-		}
-		// user selecting a particular asset?
-		if q.Select != "" && !strings.Contains(ga.Name, q.Select) {
-			continue
-		}
-		asset := Asset{
-			OS:     os,
-			Arch:   arch,
-			Name:   ga.Name,
-			URL:    url,
-			Type:   fext,
-			SHA256: sumIndex[ga.Name],
+		return err
+	})
+	var matched []Asset
+	for _, ga := range ghas {
+		if asset, ok := classifyAsset(ga, q); ok {
+			matched = append(matched, asset)
 		}
+	}
+	if err := g.Wait(); err != nil {
+		return release, nil, err
+	}
+	// dedup in release order so the choice stays deterministic
+	index := map[string]Asset{}
+	for _, asset := range matched {
+		asset.SHA256 = sumIndex[asset.Name]
 		//there can only be 1 file for each OS/Arch
 		key := asset.Key()
 		other, exists := index[key]
 		if exists {
-			gnu := func(s string) bool { return strings.Contains(s, "gnu") }
-			musl := func(s string) bool { return strings.Contains(s, "musl") }
-			g2m := gnu(other.Name) && !musl(other.Name) && !gnu(asset.Name) && musl(asset.Name)
-			// prefer musl over glib for portability, override with select=gnu
-			if !g2m {
+			// replace only with a build for the preferred libc (--libc, detected by default)
+			if libcOf(asset.Name) != q.Libc || libcOf(other.Name) == q.Libc {
 				continue
 			}
 		}
@@ -290,9 +527,113 @@ func (o Options) getAssets(q Query) (string, Assets, error) {
 	return release, assets, nil
 }
 
+// classifyAsset detects OS, arch and file type of a release asset, ok is
+// false for assets the install scripts cannot use
+func classifyAsset(ga ghAsset, q Query) (Asset, bool) {
+	url := ga.BrowserDownloadURL
+	//binary containers and linux packages are supported
+	fext := getFileExt(url)
+	if fext == "" && ga.Size > 1024*1024 {
+		fext = ".bin" // +1MB binary
+	}
+	if fext == ".exe" {
+		fext = ".bin" // raw windows binary
+	}
+	switch fext {
+	case ".bin", ".zip", ".tar.bz", ".tar.bz2", ".bz2", ".gz", ".tar.gz", ".tgz", ".deb", ".rpm":
+		// valid
+	default:
+		return Asset{}, false
+	}
+	//match
+	os := getOS(ga.Name)
+	arch := getArch(ga.Name)
+	//package names often omit the os
+	if os == "" && (fext == ".deb" || fext == ".rpm") {
+		os = "linux"
+	}
+	//windows assets are only usable by the powershell script
+	if os == "windows" && q.OS != "windows" {
+		return Asset{}, false
+	}
+	//unknown os, cant use
+	if os == "" {
+		return Asset{}, false
+	}
+	// user selecting a particular asset?
+	if q.Select != "" && !strings.Contains(ga.Name, q.Select) {
+		return Asset{}, false
+	}
+	return Asset{
+		OS:   os,
+		Arch: arch,
+		Name: ga.Name,
+		URL:  url,
+		Type: fext,
+	}, true
+}
+
+// githubRelease resolves the release tag and its assets from the GitHub API
+func (o InstallOptions) githubRelease(ctx context.Context, q Query) (string, ghAssets, error) {
+	user := q.User
+	repo := q.Program
+	release := q.Release
+	url := fmt.Sprintf("%s/repos/%s/%s/releases", githubAPI, user, repo)
+	ghas := ghAssets{}
+	if (release == "" || release == "latest") && q.Prerelease {
+		ghrs := []ghRelease{}
+		if err := o.get(ctx, url+"?per_page=100", &ghrs); err != nil {
+			return release, nil, err
+		}
+		ghr, ok := newestRelease(ghrs)
+		if !ok {
+			return release, nil, fmt.Errorf("%w: no releases", errNotFound)
+		}
+		release = ghr.TagName //discovered
+		ghas = ghr.Assets
+	} else if release == "" || release == "latest" {
+		url += "/latest"
+		ghr := ghRelease{}
+		if err := o.get(ctx, url, &ghr); err != nil {
+			return release, nil, err
+		}
+		release = ghr.TagName //discovered
+		ghas = ghr.Assets
+	} else if c, ok := parseConstraint(release); ok {
+		ghrs := []ghRelease{}
+		if err := o.get(ctx, url+"?per_page=100", &ghrs); err != nil {
+			return release, nil, err
+		}
+		ghr, ok := matchRelease(ghrs, c, q.Prerelease)
+		if !ok {
+			return release, nil, fmt.Errorf("no release matches '%s'", release)
+		}
+		release = ghr.TagName //resolved
+		ghas = ghr.Assets
+	} else {
+		ghrs := []ghRelease{}
+		if err := o.get(ctx, url, &ghrs); err != nil {
+			return release, nil, err
+		}
+		found := false
+		for _, ghr := range ghrs {
+			if ghr.TagName == release {
+				found = true
+				ghas = ghr.Assets
+				break
+			}
+		}
+		if !found {
+			return release, nil, fmt.Errorf("release tag '%s' not found", release)
+		}
+	}
+	return release, ghas, nil
+}
+
 type ghAssets []ghAsset
 
-func (as ghAssets) getSumIndex() (map[string]string, error) {
+// getSumIndex downloads the release checksum file, authenticated like the API calls
+func (o InstallOptions) getSumIndex(ctx context.Context, as ghAssets, header http.Header) (map[string]string, error) {
 	url := ""
 	for _, ga := range as {
 		//is checksum file?
@@ -302,13 +643,24 @@ func (as ghAssets) getSumIndex() (map[string]string, error) {
 		}
 	}
 	if url == "" {
-		return nil, errors.New("no sum file found")
+		return nil, errNoSumFile
 	}
-	resp, err := http.DefaultClient.Get(url)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, vs := range header {
+		req.Header[k] = vs
+	}
+	o.setToken(req)
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("sum file download failed: %s: %s", url, resp.Status)
+	}
 	// take each line and insert into the index
 	index := map[string]string{}
 	s := bufio.NewScanner(resp.Body)
@@ -317,7 +669,7 @@ func (as ghAssets) getSumIndex() (map[string]string, error) {
 		if len(fs) != 2 {
 			continue
 		}
-		index[fs[1]] = fs[0]
+		index[strings.TrimPrefix(fs[1], "*")] = strings.ToLower(fs[0])
 	}
 	if err := s.Err(); err != nil {
 		return nil, err
@@ -347,6 +699,30 @@ func (g ghAsset) IsChecksumFile() bool {
 	return checksumRe.MatchString(strings.ToLower(g.Name)) && g.Size < 64*1024 //maximum file size 64KB
 }
 
+// newestRelease picks the highest version among the published releases,
+// prereleases included, falling back to publish time for non-semver tags
+func newestRelease(ghrs []ghRelease) (ghRelease, bool) {
+	var best ghRelease
+	found := false
+	for _, ghr := range ghrs {
+		if ghr.Draft {
+			continue
+		}
+		if !found {
+			best, found = ghr, true
+			continue
+		}
+		if c, ok := compareVersions(ghr.TagName, best.TagName); ok {
+			if c > 0 {
+				best = ghr
+			}
+		} else if ghr.PublishedAt > best.PublishedAt {
+			best = ghr
+		}
+	}
+	return best, found
+}
+
 type ghRelease struct {
 	Assets    []ghAsset `json:"assets"`
 	AssetsURL string    `json:"assets_url"`
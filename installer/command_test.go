@@ -0,0 +1,290 @@
+package installer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTokenSentToGitHub(t *testing.T) {
+	const sum = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	var (
+		mu   sync.Mutex
+		auth = map[string]string{}
+	)
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		auth[r.URL.Path] = r.Header.Get("Authorization")
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/repos/user/prog/releases/latest":
+			json.NewEncoder(w).Encode(ghRelease{
+				TagName: "v1.0.0",
+				Assets: []ghAsset{
+					{Name: "prog_linux_amd64.tar.gz", BrowserDownloadURL: srv.URL + "/dl/prog_linux_amd64.tar.gz"},
+					{Name: "checksums.txt", BrowserDownloadURL: srv.URL + "/dl/checksums.txt", Size: 100},
+				},
+			})
+		case "/dl/checksums.txt":
+			fmt.Fprintf(w, "%s  prog_linux_amd64.tar.gz\n", sum)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	defer func(api string) { githubAPI = api }(githubAPI)
+	githubAPI = srv.URL
+
+	o := InstallOptions{ResolveOptions: ResolveOptions{Token: "secret"}}
+	release, assets, err := o.getAssets(context.Background(), Query{User: "user", Program: "prog", Release: "latest"})
+	if err != nil {
+		t.Fatalf("getAssets: %v", err)
+	}
+	if release != "v1.0.0" {
+		t.Errorf("release = %q, want v1.0.0", release)
+	}
+	if len(assets) != 1 || assets[0].SHA256 != sum {
+		t.Errorf("assets = %+v, want one asset with sha256 %s", assets, sum)
+	}
+	for _, path := range []string{"/repos/user/prog/releases/latest", "/dl/checksums.txt"} {
+		if got := auth[path]; got != "token secret" {
+			t.Errorf("Authorization for %s = %q, want %q", path, got, "token secret")
+		}
+	}
+}
+
+func TestTokenNotSentToOtherHosts(t *testing.T) {
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("Authorization")
+		fmt.Fprintln(w, "{}")
+	}))
+	defer srv.Close()
+
+	o := InstallOptions{ResolveOptions: ResolveOptions{Token: "secret"}}
+	var v struct{}
+	if err := o.get(context.Background(), srv.URL, &v); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if got != "" {
+		t.Errorf("Authorization = %q, want none for a host other than the GitHub API", got)
+	}
+}
+
+func TestGetRetriesServerErrors(t *testing.T) {
+	defer func(d time.Duration) { retryBase = d }(retryBase)
+	retryBase = time.Millisecond
+
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= 2 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, `{"tag_name":"v1.0.0"}`)
+	}))
+	defer srv.Close()
+
+	var ghr ghRelease
+	if err := (InstallOptions{ResolveOptions: ResolveOptions{Retries: 3}}).get(context.Background(), srv.URL, &ghr); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if ghr.TagName != "v1.0.0" {
+		t.Errorf("tag = %q, want v1.0.0", ghr.TagName)
+	}
+	if n := calls.Load(); n != 3 {
+		t.Errorf("requests = %d, want 3", n)
+	}
+}
+
+func TestGetNoRetryOnClientErrors(t *testing.T) {
+	defer func(d time.Duration) { retryBase = d }(retryBase)
+	retryBase = time.Millisecond
+
+	for _, code := range []int{http.StatusNotFound, http.StatusUnauthorized} {
+		var calls atomic.Int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.WriteHeader(code)
+		}))
+		var v struct{}
+		err := (InstallOptions{ResolveOptions: ResolveOptions{Retries: 3}}).get(context.Background(), srv.URL, &v)
+		srv.Close()
+		if err == nil {
+			t.Fatalf("%d: expected an error", code)
+		}
+		if code == http.StatusNotFound && !errors.Is(err, errNotFound) {
+			t.Errorf("404: error %v does not wrap errNotFound", err)
+		}
+		if n := calls.Load(); n != 1 {
+			t.Errorf("%d: requests = %d, want 1", code, n)
+		}
+	}
+}
+
+func TestGetAssetsSumFile(t *testing.T) {
+	sumFile, sumStatus := true, http.StatusOK
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/dl/checksums.txt" {
+			w.WriteHeader(sumStatus)
+			fmt.Fprintf(w, "%064x  prog_linux_amd64.tar.gz\n", 1)
+			return
+		}
+		ghr := ghRelease{TagName: "v1.0.0", Assets: []ghAsset{{Name: "prog_linux_amd64.tar.gz", BrowserDownloadURL: srv.URL + "/dl/prog_linux_amd64.tar.gz"}}}
+		if sumFile {
+			ghr.Assets = append(ghr.Assets, ghAsset{Name: "checksums.txt", BrowserDownloadURL: srv.URL + "/dl/checksums.txt"})
+		}
+		json.NewEncoder(w).Encode(ghr)
+	}))
+	defer srv.Close()
+	defer func(api string) { githubAPI = api }(githubAPI)
+	githubAPI = srv.URL
+
+	q := Query{User: "user", Program: "prog", Release: "latest"}
+	_, assets, err := (InstallOptions{}).getAssets(context.Background(), q)
+	if err != nil || len(assets) != 1 || assets[0].SHA256 != fmt.Sprintf("%064x", 1) {
+		t.Fatalf("with sum file: %v, %v", assets, err)
+	}
+
+	// a release without checksum file still resolves, its assets have no sum
+	sumFile = false
+	if _, assets, err = (InstallOptions{}).getAssets(context.Background(), q); err != nil || len(assets) != 1 || assets[0].SHA256 != "" {
+		t.Fatalf("without sum file: %v, %v", assets, err)
+	}
+
+	// a published checksum file that fails to download is an error
+	sumFile, sumStatus = true, http.StatusInternalServerError
+	if _, _, err = (InstallOptions{}).getAssets(context.Background(), q); err == nil || !strings.Contains(err.Error(), "sum file download failed") {
+		t.Errorf("failing sum file: err = %v", err)
+	}
+}
+
+func BenchmarkGetAssets(b *testing.B) {
+	platforms := []string{"linux_amd64", "linux_arm64", "linux_386", "darwin_amd64", "darwin_arm64", "freebsd_amd64", "netbsd_amd64", "openbsd_amd64", "windows_amd64", "linux_armv7"}
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/dl/checksums.txt" {
+			for i := 0; i < 100; i++ {
+				fmt.Fprintf(w, "%064x  prog_%d_%s.tar.gz\n", i, i/len(platforms), platforms[i%len(platforms)])
+			}
+			return
+		}
+		ghr := ghRelease{TagName: "v1.0.0"}
+		for i := 0; i < 100; i++ {
+			name := fmt.Sprintf("prog_%d_%s.tar.gz", i/len(platforms), platforms[i%len(platforms)])
+			ghr.Assets = append(ghr.Assets, ghAsset{Name: name, BrowserDownloadURL: srv.URL + "/dl/" + name})
+		}
+		ghr.Assets = append(ghr.Assets, ghAsset{Name: "checksums.txt", BrowserDownloadURL: srv.URL + "/dl/checksums.txt", Size: 8000})
+		json.NewEncoder(w).Encode(ghr)
+	}))
+	defer srv.Close()
+	defer func(api string) { githubAPI = api }(githubAPI)
+	githubAPI = srv.URL
+
+	q := Query{User: "user", Program: "prog", Release: "latest"}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := (InstallOptions{}).getAssets(context.Background(), q); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestQuerySearchFallback(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/owner/prog/releases/latest" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(ghRelease{
+			TagName: "v1.0.0",
+			Assets:  []ghAsset{{Name: "prog_linux_amd64.tar.gz", BrowserDownloadURL: srv.URL + "/dl/prog_linux_amd64.tar.gz"}},
+		})
+	}))
+	defer srv.Close()
+	defer func(api string) { githubAPI = api }(githubAPI)
+	githubAPI = srv.URL
+
+	var searched string
+	o := InstallOptions{Searcher: SearcherFunc(func(ctx context.Context, program string) (string, string, error) {
+		searched = program
+		return "owner", program, nil
+	})}
+	q := Query{}
+	q.setRepo("prog")
+	r, err := o.query(context.Background(), q)
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if searched != "prog" || r.User != "owner" || r.ResolvedRelease != "v1.0.0" {
+		t.Errorf("searched %q, resolved %s/%s %s", searched, r.User, r.Program, r.ResolvedRelease)
+	}
+
+	o.NoSearch = true
+	searched = ""
+	if _, err := o.query(context.Background(), q); !errors.Is(err, errNotFound) || searched != "" {
+		t.Errorf("with --no-search: err = %v, searched %q", err, searched)
+	}
+}
+
+func TestShellScriptQuotesBinDir(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not available")
+	}
+	binDir := `~/my bin/"$(touch pwned)"/it's`
+	script, err := RenderShell(QueryResult{Query: Query{MoveToPath: true, BinDir: binDir}})
+	if err != nil {
+		t.Fatalf("RenderShell: %v", err)
+	}
+
+	// evaluate only the OUT_DIR assignments (including ~ expansion) and compare with the input
+	var lines []string
+	for _, l := range strings.Split(script, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(l), "OUT_DIR=") {
+			lines = append(lines, l)
+		}
+	}
+	lines = append(lines, `printf %s "$OUT_DIR"`)
+	cmd := exec.Command(bash, "-c", strings.Join(lines, "\n"))
+	cmd.Dir = t.TempDir()
+	cmd.Env = []string{"HOME=/home/u"}
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("bash: %v\n%s", err, out)
+	}
+	if want := `/home/u/my bin/"$(touch pwned)"/it's`; string(out) != want {
+		t.Errorf("OUT_DIR = %q, want %q", out, want)
+	}
+}
+
+func TestPowerShellScriptScopesToken(t *testing.T) {
+	script, err := RenderPowerShell(QueryResult{Query: Query{DownloadHeaders: []string{"X-Api-Key: k"}}})
+	if err != nil {
+		t.Fatalf("RenderPowerShell: %v", err)
+	}
+	// the token is added with its scheme, only for GitHub hosts, right before the download
+	auth := strings.Index(script, `$Headers["Authorization"] = "token $env:GITHUB_TOKEN"`)
+	if auth < 0 || strings.Count(script, `$Headers["Authorization"]`) != 1 {
+		t.Fatalf("want a single prefixed Authorization header in:\n%s", script)
+	}
+	guard := script[strings.LastIndex(script[:auth], "if ("):auth]
+	if !strings.Contains(guard, `"github.com", "api.github.com"`) {
+		t.Errorf("Authorization header is not limited to GitHub hosts: %s", guard)
+	}
+	if !strings.Contains(script, `$Headers['X-Api-Key'] = 'k'`) {
+		t.Errorf("download header missing in:\n%s", script)
+	}
+}
@@ -0,0 +1,102 @@
+package installer
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+type glRelease struct {
+	TagName         string `json:"tag_name"`
+	Name            string `json:"name"`
+	ReleasedAt      string `json:"released_at"`
+	UpcomingRelease bool   `json:"upcoming_release"`
+	Assets          struct {
+		Links []glLink `json:"links"`
+	} `json:"assets"`
+}
+
+type glLink struct {
+	ID             int    `json:"id"`
+	Name           string `json:"name"`
+	URL            string `json:"url"`
+	DirectAssetURL string `json:"direct_asset_url"`
+	LinkType       string `json:"link_type"`
+}
+
+// toGitHub maps a GitLab release onto the GitHub structures used for asset matching
+func (r glRelease) toGitHub() ghRelease {
+	ghr := ghRelease{
+		TagName:     r.TagName,
+		Name:        r.Name,
+		Prerelease:  r.UpcomingRelease,
+		PublishedAt: r.ReleasedAt,
+	}
+	for _, l := range r.Assets.Links {
+		u := l.DirectAssetURL
+		if u == "" {
+			u = l.URL
+		}
+		ghr.Assets = append(ghr.Assets, ghAsset{
+			ID:                 l.ID,
+			Name:               l.Name,
+			BrowserDownloadURL: u,
+			URL:                l.URL,
+		})
+	}
+	return ghr
+}
+
+// gitlabBase returns the GitLab instance URL without trailing slash
+func (o InstallOptions) gitlabBase() string {
+	if o.GitlabURL == "" {
+		return "https://gitlab.com"
+	}
+	return strings.TrimSuffix(o.GitlabURL, "/")
+}
+
+// gitlabRelease resolves the release tag and its asset links from the GitLab releases API
+func (o InstallOptions) gitlabRelease(ctx context.Context, q Query) (string, ghAssets, error) {
+	release := q.Release
+	project := url.PathEscape(q.User + "/" + q.Program)
+	u := fmt.Sprintf("%s/api/v4/projects/%s/releases", o.gitlabBase(), project)
+
+	c, isRange := parseConstraint(release)
+	if release != "" && release != "latest" && !isRange {
+		glr := glRelease{}
+		if err := o.get(ctx, u+"/"+url.PathEscape(release), &glr); err != nil {
+			return release, nil, err
+		}
+		return release, glr.toGitHub().Assets, nil
+	}
+
+	glrs := []glRelease{}
+	if err := o.get(ctx, u+"?per_page=100", &glrs); err != nil {
+		return release, nil, err
+	}
+	ghrs := make([]ghRelease, 0, len(glrs))
+	if isRange {
+		for _, glr := range glrs {
+			ghrs = append(ghrs, glr.toGitHub())
+		}
+		ghr, ok := matchRelease(ghrs, c, q.Prerelease)
+		if !ok {
+			return release, nil, fmt.Errorf("no release matches '%s'", release)
+		}
+		return ghr.TagName, ghr.Assets, nil
+	}
+	for _, glr := range glrs {
+		ghr := glr.toGitHub()
+		// upcoming releases only count as "latest" with --prerelease
+		if ghr.Prerelease && !q.Prerelease {
+			continue
+		}
+		ghrs = append(ghrs, ghr)
+	}
+	ghr, ok := newestRelease(ghrs)
+	if !ok {
+		return release, nil, fmt.Errorf("%w: no releases", errNotFound)
+	}
+	return ghr.TagName, ghr.Assets, nil
+}
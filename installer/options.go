@@ -1,14 +1,53 @@
 package installer
 
+import "time"
+
 type Options struct {
-	Repo string `arg:"" help:"GitHub repository."`
+	Install InstallOptions `cmd:"" name:"get" default:"withargs" help:"Install binary from GitHub release (default)."`
+	Verify  VerifyOptions  `cmd:"" name:"verify" help:"Verify an installed binary against the release checksum."`
+}
+
+// ResolveOptions are the flags shared by the commands that look up a release
+// and pick the asset for a platform
+type ResolveOptions struct {
+	Token          string        `help:"GitHub token." short:"t" env:"GITHUB_TOKEN"`
+	Select         string        `help:"Select from list of available releases."`
+	Prerelease     bool          `help:"Let 'latest' and version ranges (@^1.4, @~2.1.0) resolve to prereleases too."`
+	Source         string        `help:"Release source, 'github' or 'gitlab'; --token is sent as PRIVATE-TOKEN for GitLab." enum:"github,gitlab" default:"github"`
+	GitlabURL      string        `help:"GitLab instance used with --source gitlab." name:"gitlab-url" default:"https://gitlab.com" env:"GITLAB_URL"`
+	Os             string        `help:"Target OS instead of the current one."`
+	Arch           string        `help:"Target architecture instead of the current one."`
+	Libc           string        `help:"Preferred libc when a release has both musl and gnu linux builds: auto, musl or gnu." enum:"auto,musl,gnu" default:"auto"`
+	DownloadHeader []string      `help:"Extra 'Key: Value' header for asset and checksum downloads (repeatable)." name:"download-header"`
+	Timeout        time.Duration `help:"Overall timeout for GitHub and download requests, 0 for none." default:"2m"`
+	Retries        int           `help:"Retries for release API requests failing with network errors or 5xx responses." default:"3"`
+}
+
+type InstallOptions struct {
+	Repo string `arg:"" help:"GitHub repository, optionally with @tag, @latest or a version range like @^1.4."`
+
+	ResolveOptions `embed:""`
 
-	Output    string `help:"Output format, can be 'shell', 'json'" default:"shell" short:"o"`
-	Token     string `help:"GitHub token." short:"t" env:"GITHUB_TOKEN"`
+	Output    string `help:"Output format, can be 'shell', 'powershell', 'json'" default:"shell" short:"o"`
 	Insecure  bool   `help:"Allow insecure connections." short:"k"`
 	AsProgram string `help:"Install as different name."`
-	Select    string `help:"Select from list of available releases."`
-	Os        string `help:"Install for different OS."`
-	Arch      string `help:"Install for different architecture."`
-	Move      bool   `help:"Move binary to /usr/local/bin."`
+	Move      bool   `help:"Move binary to --bin-dir."`
+	BinDir    string `help:"Directory to move the binary into, created if missing." default:"/usr/local/bin" aliases:"dir"`
+
+	Verify     bool          `help:"Download the asset for the target platform and check its checksum before printing the script."`
+	List       bool          `help:"Only list the assets matched for the resolved release, no script is printed."`
+	SearchWith string        `help:"How to find the user of a repository given by name only: 'web' (DuckDuckGo/Google) or 'github' (search API)." enum:"web,github" default:"web"`
+	NoSearch   bool          `help:"Fail instead of searching when the repository is given without user."`
+	CacheTTL   time.Duration `help:"How long resolved releases are cached under the user cache directory." name:"cache-ttl" default:"1h"`
+	NoCache    bool          `help:"Always query the release API, bypassing the cache."`
+
+	// Searcher overrides SearchWith, for library use and tests
+	Searcher Searcher `kong:"-"`
+}
+
+type VerifyOptions struct {
+	Repo string `arg:"" help:"GitHub repository, optionally with @tag, @latest or a version range like @^1.4."`
+	Path string `arg:"" help:"Installed binary to check." type:"existingfile"`
+
+	ResolveOptions `embed:""`
 }
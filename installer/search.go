@@ -1,28 +1,78 @@
 package installer
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
 	"regexp"
+	"strings"
 )
 
 var searchGithubRe = regexp.MustCompile(`https:\/\/github\.com\/(\w+)\/(\w+)`)
 
-func imFeelingLuck(phrase string) (user, project string, err error) {
+// Searcher finds the GitHub repository of a program when only its name was given
+type Searcher interface {
+	Find(ctx context.Context, program string) (user, project string, err error)
+}
+
+// SearcherFunc adapts a function to the Searcher interface
+type SearcherFunc func(ctx context.Context, program string) (user, project string, err error)
+
+func (f SearcherFunc) Find(ctx context.Context, program string) (string, string, error) {
+	return f(ctx, program)
+}
+
+// WebSearcher uses the "I'm feeling lucky" redirects of DuckDuckGo and Google
+var WebSearcher Searcher = SearcherFunc(imFeelingLuck)
+
+// GitHubSearcher uses the GitHub repository search API, preferring an exact
+// name match among the most starred results
+type GitHubSearcher struct {
+	Token string
+}
+
+func (s GitHubSearcher) Find(ctx context.Context, program string) (string, string, error) {
+	v := url.Values{}
+	v.Set("q", program+" in:name")
+	v.Set("sort", "stars")
+	v.Set("per_page", "10")
+	var res struct {
+		Items []struct {
+			Name  string `json:"name"`
+			Owner struct {
+				Login string `json:"login"`
+			} `json:"owner"`
+		} `json:"items"`
+	}
+	if err := (InstallOptions{ResolveOptions: ResolveOptions{Token: s.Token}}).get(ctx, githubAPI+"/search/repositories?"+v.Encode(), &res); err != nil {
+		return "", "", err
+	}
+	if len(res.Items) == 0 {
+		return "", "", errors.New("not found")
+	}
+	for _, it := range res.Items {
+		if strings.EqualFold(it.Name, program) {
+			return it.Owner.Login, it.Name, nil
+		}
+	}
+	return res.Items[0].Owner.Login, res.Items[0].Name, nil
+}
+
+func imFeelingLuck(ctx context.Context, phrase string) (user, project string, err error) {
 	phrase += " site:github.com"
 	// try dgg
 	v := url.Values{}
 	v.Set("q", "! " /*I'm feeling lucky*/ +phrase)
-	if user, project, err := captureRepoLocation(("https://html.duckduckgo.com/html?" + v.Encode())); err == nil {
+	if user, project, err := captureRepoLocation(ctx, ("https://html.duckduckgo.com/html?" + v.Encode())); err == nil {
 		return user, project, nil
 	}
 	// try google
 	v = url.Values{}
 	v.Set("btnI", "") //I'm feeling lucky
 	v.Set("q", phrase)
-	if user, project, err := captureRepoLocation(("https://www.google.com/search?" + v.Encode())); err == nil {
+	if user, project, err := captureRepoLocation(ctx, ("https://www.google.com/search?" + v.Encode())); err == nil {
 		return user, project, nil
 	}
 	return "", "", errors.New("not found")
@@ -30,8 +80,8 @@ func imFeelingLuck(phrase string) (user, project string, err error) {
 
 // uses im feeling lucky and grabs the "Location"
 // header from the 302, which contains the github repo
-func captureRepoLocation(url string) (user, project string, err error) {
-	req, err := http.NewRequest("GET", url, nil)
+func captureRepoLocation(ctx context.Context, url string) (user, project string, err error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		panic(err)
 	}
@@ -0,0 +1,172 @@
+package installer
+
+import (
+	"strconv"
+	"strings"
+)
+
+// versionBound is a single comparison such as ">=1.4.0"
+type versionBound struct {
+	op, version string
+}
+
+// versionConstraint is a set of bounds that must all hold, parsed from
+// release ranges like "^1.4", "~2.1.0", "1.x" or ">=1.2 <1.5"
+type versionConstraint []versionBound
+
+// parseConstraint parses the release part of user/prog@release, ok is false
+// for plain tags (including exact versions such as "v1.2.3") and "latest"
+func parseConstraint(s string) (versionConstraint, bool) {
+	var c versionConstraint
+	for _, f := range strings.FieldsFunc(s, func(r rune) bool { return r == ' ' || r == ',' }) {
+		bs, ok := parseBound(f)
+		if !ok {
+			return nil, false
+		}
+		c = append(c, bs...)
+	}
+	return c, len(c) > 0
+}
+
+func parseBound(s string) ([]versionBound, bool) {
+	op := ""
+	for _, p := range []string{">=", "<=", ">", "<", "=", "^", "~"} {
+		if strings.HasPrefix(s, p) {
+			op, s = p, strings.TrimSpace(s[len(p):])
+			break
+		}
+	}
+	// split into numeric parts, missing or wildcard parts are open
+	core, pre, _ := strings.Cut(strings.TrimPrefix(s, "v"), "-")
+	parts := strings.Split(core, ".")
+	if len(parts) > 3 {
+		return nil, false
+	}
+	var nums []int
+	wildcard := false
+	for _, p := range parts {
+		if p == "x" || p == "X" || p == "*" {
+			wildcard = true
+			break
+		}
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return nil, false
+		}
+		nums = append(nums, n)
+	}
+	// a bare version without operator or wildcard is an exact tag, not a range
+	if op == "" && !wildcard {
+		return nil, false
+	}
+	if wildcard && op != "" && op != "=" {
+		return nil, false
+	}
+	lower := joinVersion(nums, pre)
+	switch {
+	case wildcard || (op == "=" && len(nums) < 3) || op == "~":
+		// 1.x, =1.2 and ~1.2.3 allow changes below the last given part
+		// (~1 behaves like 1.x)
+		upper := nums
+		if op == "~" && len(nums) > 1 {
+			upper = nums[:2]
+		}
+		if len(upper) == 0 {
+			return []versionBound{{">=", "0.0.0"}}, true
+		}
+		return []versionBound{{">=", lower}, {"<", bump(upper)}}, true
+	case op == "^":
+		// ^ allows changes below the first non-zero part
+		upper := nums
+		for i, n := range nums {
+			if n != 0 || i == len(nums)-1 {
+				upper = nums[:i+1]
+				break
+			}
+		}
+		return []versionBound{{">=", lower}, {"<", bump(upper)}}, true
+	}
+	return []versionBound{{op, lower}}, true
+}
+
+// joinVersion pads nums to a full x.y.z version
+func joinVersion(nums []int, pre string) string {
+	v := make([]string, 3)
+	for i := range v {
+		v[i] = "0"
+		if i < len(nums) {
+			v[i] = strconv.Itoa(nums[i])
+		}
+	}
+	s := strings.Join(v, ".")
+	if pre != "" {
+		s += "-" + pre
+	}
+	return s
+}
+
+// bump increments the last part of nums, returning the lowest prerelease
+// of that version so prereleases of the next version are excluded too
+func bump(nums []int) string {
+	next := append([]int(nil), nums...)
+	next[len(next)-1]++
+	return joinVersion(next, "0")
+}
+
+// matches reports whether tag is a version satisfying every bound
+func (c versionConstraint) matches(tag string) bool {
+	for _, b := range c {
+		cmp, ok := compareVersions(tag, b.version)
+		if !ok {
+			return false
+		}
+		switch b.op {
+		case ">=":
+			ok = cmp >= 0
+		case ">":
+			ok = cmp > 0
+		case "<=":
+			ok = cmp <= 0
+		case "<":
+			ok = cmp < 0
+		default:
+			ok = cmp == 0
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// matchRelease picks the highest release satisfying c, drafts and (unless
+// prerelease is set) prereleases are skipped
+func matchRelease(ghrs []ghRelease, c versionConstraint, prerelease bool) (ghRelease, bool) {
+	var best ghRelease
+	found := false
+	for _, ghr := range ghrs {
+		if ghr.Draft || !c.matches(ghr.TagName) {
+			continue
+		}
+		if !prerelease && (ghr.Prerelease || isPrereleaseTag(ghr.TagName)) {
+			continue
+		}
+		if cmp, _ := compareVersions(ghr.TagName, best.TagName); !found || cmp > 0 {
+			best, found = ghr, true
+		}
+	}
+	return best, found
+}
+
+func isPrereleaseTag(tag string) bool {
+	m := versionRe.FindStringSubmatch(tag)
+	return m != nil && m[4] != ""
+}
+
+func (c versionConstraint) String() string {
+	bs := make([]string, len(c))
+	for i, b := range c {
+		bs[i] = b.op + b.version
+	}
+	return strings.Join(bs, " ")
+}
@@ -1,7 +1,9 @@
 package installer
 
 import (
+	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
 )
 
@@ -10,6 +12,7 @@ var (
 	fileExtRe  = regexp.MustCompile(`(\.tar)?(\.[a-z][a-z0-9]+)$`)
 	posixOSRe  = regexp.MustCompile(`(darwin|linux|(net|free|open)bsd|mac|osx|windows|win)`)
 	checksumRe = regexp.MustCompile(`(checksums|sha256sums)`)
+	versionRe  = regexp.MustCompile(`^v?(\d+)(?:\.(\d+))?(?:\.(\d+))?(?:-([0-9A-Za-z.-]+))?(?:\+[0-9A-Za-z.-]+)?$`)
 )
 
 func getOS(s string) string {
@@ -49,3 +52,101 @@ func splitHalf(s, by string) (string, string) {
 	}
 	return s[:i], s[i+len(by):]
 }
+
+// compareVersions compares two semver-like tags ("v1.2.3", "1.2.3-rc.1"),
+// ok is false when either tag is not a version
+func compareVersions(a, b string) (int, bool) {
+	am, ok := versionRe.FindStringSubmatch(a), versionRe.MatchString(b)
+	if am == nil || !ok {
+		return 0, false
+	}
+	bm := versionRe.FindStringSubmatch(b)
+	for i := 1; i <= 3; i++ {
+		an, _ := strconv.Atoi(am[i])
+		bn, _ := strconv.Atoi(bm[i])
+		if an != bn {
+			return cmpInt(an, bn), true
+		}
+	}
+	// a release is higher than any of its prereleases
+	switch {
+	case am[4] == bm[4]:
+		return 0, true
+	case am[4] == "":
+		return 1, true
+	case bm[4] == "":
+		return -1, true
+	}
+	ap, bp := strings.Split(am[4], "."), strings.Split(bm[4], ".")
+	for i := 0; i < len(ap) && i < len(bp); i++ {
+		an, aerr := strconv.Atoi(ap[i])
+		bn, berr := strconv.Atoi(bp[i])
+		switch {
+		case aerr == nil && berr == nil:
+			if an != bn {
+				return cmpInt(an, bn), true
+			}
+		case aerr == nil:
+			return -1, true
+		case berr == nil:
+			return 1, true
+		case ap[i] != bp[i]:
+			return strings.Compare(ap[i], bp[i]), true
+		}
+	}
+	return cmpInt(len(ap), len(bp)), true
+}
+
+func cmpInt(a, b int) int {
+	if a < b {
+		return -1
+	}
+	if a > b {
+		return 1
+	}
+	return 0
+}
+
+// parseHeader splits a "Key: Value" flag into its parts
+func parseHeader(s string) (string, string, error) {
+	k, v, ok := strings.Cut(s, ":")
+	k = strings.TrimSpace(k)
+	if !ok || k == "" || strings.ContainsAny(k, " \t") {
+		return "", "", fmt.Errorf("invalid header %q, expected 'Key: Value'", s)
+	}
+	return k, strings.TrimSpace(v), nil
+}
+
+// parseHeaders validates --download-header flags and normalizes them to "Key: Value"
+func parseHeaders(hs []string) ([]string, error) {
+	var out []string
+	for _, h := range hs {
+		k, v, err := parseHeader(h)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, k+": "+v)
+	}
+	return out, nil
+}
+
+// psQuote wraps s in single quotes for use in the generated PowerShell script
+func psQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// headerKey and headerValue split a normalized "Key: Value" header for the templates
+func headerKey(h string) string {
+	k, _, _ := strings.Cut(h, ":")
+	return strings.TrimSpace(k)
+}
+
+func headerValue(h string) string {
+	_, v, _ := strings.Cut(h, ":")
+	return strings.TrimSpace(v)
+}
+
+// shellQuote wraps s in single quotes for use in the generated script
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
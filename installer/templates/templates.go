@@ -4,3 +4,6 @@ import _ "embed"
 
 //go:embed install.sh.tmpl
 var Shell []byte
+
+//go:embed install.ps1.tmpl
+var PowerShell []byte
@@ -0,0 +1,229 @@
+package installer
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/bzip2"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"runtime"
+	"strings"
+)
+
+func (o VerifyOptions) Run() error {
+	q, err := o.baseQuery(o.Repo)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := withTimeout(o.Timeout)
+	defer cancel()
+	result, err := InstallOptions{ResolveOptions: o.ResolveOptions}.query(ctx, q)
+	if err != nil {
+		return fmt.Errorf("query failed: %w", err)
+	}
+	asset, err := result.pick(o.Os, o.Arch)
+	if err != nil {
+		return err
+	}
+	if asset.SHA256 == "" {
+		return fmt.Errorf("release %s publishes no checksum for %s", result.ResolvedRelease, asset.Name)
+	}
+
+	local, err := fileSHA256(o.Path)
+	if err != nil {
+		return err
+	}
+	// raw binaries are checked directly, archives are downloaded,
+	// checked against the published sum and the binary inside compared
+	expected := asset.SHA256
+	if asset.Type != ".bin" {
+		expected, err = archivedBinarySHA256(ctx, asset, q.downloadHeader())
+		if err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("release:  %s/%s %s\n", result.User, result.Program, result.ResolvedRelease)
+	fmt.Printf("asset:    %s (sha256 %s)\n", asset.Name, asset.SHA256)
+	fmt.Printf("expected: %s\n", expected)
+	fmt.Printf("local:    %s  %s\n", local, o.Path)
+	if local != expected {
+		return errors.New("checksum mismatch")
+	}
+	fmt.Println("OK")
+	return nil
+}
+
+// pick selects the asset the install script would choose for the given platform,
+// defaulting to the current one
+func (r QueryResult) pick(goos, goarch string) (Asset, error) {
+	if goos == "" {
+		goos = runtime.GOOS
+	}
+	if goarch == "" {
+		goarch = runtime.GOARCH
+		// no m1 assets, rosetta allows fallback to amd64
+		if goos == "darwin" && goarch == "arm64" && !r.M1Asset {
+			goarch = "amd64"
+		}
+	}
+	for _, a := range r.Assets {
+		if a.OS == goos && a.Arch == goarch && !a.IsPackage() {
+			return a, nil
+		}
+	}
+	return Asset{}, fmt.Errorf("no asset for platform %s-%s", goos, goarch)
+}
+
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// downloadVerified downloads the asset to a temporary file and checks it
+// against the published checksum, the caller must close and remove the file
+func downloadVerified(ctx context.Context, a Asset, header http.Header) (*os.File, int64, error) {
+	if a.SHA256 == "" {
+		return nil, 0, fmt.Errorf("no checksum published for %s", a.Name)
+	}
+	tmp, err := os.CreateTemp("", "myUtilities-verify-*")
+	if err != nil {
+		return nil, 0, err
+	}
+	fail := func(err error) (*os.File, int64, error) {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return nil, 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", a.URL, nil)
+	if err != nil {
+		return fail(err)
+	}
+	for k, vs := range header {
+		req.Header[k] = vs
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fail(fmt.Errorf("download failed: %s: %s", a.URL, err))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return fail(fmt.Errorf("download failed: %s: %s", a.URL, resp.Status))
+	}
+	h := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, h), resp.Body)
+	if err != nil {
+		return fail(fmt.Errorf("download failed: %s: %s", a.URL, err))
+	}
+	if sum := hex.EncodeToString(h.Sum(nil)); !strings.EqualFold(sum, a.SHA256) {
+		return fail(fmt.Errorf("checksum mismatch for %s: expected %s, got %s", a.Name, a.SHA256, sum))
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return fail(err)
+	}
+	return tmp, size, nil
+}
+
+// archivedBinarySHA256 downloads the asset, checks it against the published
+// checksum and returns the sha256 of the binary inside (the largest file)
+func archivedBinarySHA256(ctx context.Context, a Asset, header http.Header) (string, error) {
+	tmp, size, err := downloadVerified(ctx, a, header)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	switch a.Type {
+	case ".zip":
+		return largestInZip(tmp, size)
+	case ".tar.gz", ".tgz":
+		gz, err := gzip.NewReader(tmp)
+		if err != nil {
+			return "", err
+		}
+		return largestInTar(tar.NewReader(gz))
+	case ".tar.bz", ".tar.bz2":
+		return largestInTar(tar.NewReader(bzip2.NewReader(tmp)))
+	case ".gz":
+		gz, err := gzip.NewReader(tmp)
+		if err != nil {
+			return "", err
+		}
+		return readerSHA256(gz)
+	case ".bz2":
+		return readerSHA256(bzip2.NewReader(tmp))
+	}
+	return "", fmt.Errorf("unknown file type: %s", a.Type)
+}
+
+func readerSHA256(r io.Reader) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func largestInTar(tr *tar.Reader) (string, error) {
+	sum, largest := "", int64(-1)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		if hdr.Typeflag != tar.TypeReg || hdr.Size <= largest {
+			continue
+		}
+		if sum, err = readerSHA256(tr); err != nil {
+			return "", err
+		}
+		largest = hdr.Size
+	}
+	if largest < 0 {
+		return "", errors.New("no file found in archive")
+	}
+	return sum, nil
+}
+
+func largestInZip(r io.ReaderAt, size int64) (string, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return "", err
+	}
+	var largest *zip.File
+	for _, f := range zr.File {
+		if f.FileInfo().Mode().IsRegular() && (largest == nil || f.UncompressedSize64 > largest.UncompressedSize64) {
+			largest = f
+		}
+	}
+	if largest == nil {
+		return "", errors.New("no file found in archive")
+	}
+	rc, err := largest.Open()
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+	return readerSHA256(rc)
+}
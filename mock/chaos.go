@@ -0,0 +1,65 @@
+package mock
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// latencyRange 响应延迟，max大于min时在区间内随机取值
+type latencyRange struct {
+	min, max time.Duration
+}
+
+// parseLatency 解析固定延迟（如 200ms）或区间（如 100ms-500ms），空字符串表示无延迟
+func parseLatency(s string) (latencyRange, error) {
+	if s == "" {
+		return latencyRange{}, nil
+	}
+	lo, hi, isRange := strings.Cut(s, "-")
+	min, err := time.ParseDuration(strings.TrimSpace(lo))
+	if err != nil {
+		return latencyRange{}, fmt.Errorf("invalid latency %q: %w", s, err)
+	}
+	max := min
+	if isRange {
+		if max, err = time.ParseDuration(strings.TrimSpace(hi)); err != nil {
+			return latencyRange{}, fmt.Errorf("invalid latency %q: %w", s, err)
+		}
+	}
+	if min < 0 || max < min {
+		return latencyRange{}, fmt.Errorf("invalid latency %q: expected a non-negative duration or min-max range", s)
+	}
+	return latencyRange{min: min, max: max}, nil
+}
+
+// pick 返回本次请求的延迟
+func (l latencyRange) pick() time.Duration {
+	if l.max > l.min {
+		return l.min + rand.N(l.max-l.min+1)
+	}
+	return l.min
+}
+
+// misbehave 按配置的延迟等待，并按--error-rate的概率注入错误：
+// 一半返回HTTP 500，一半返回Code为9的错误信封；返回true表示已写出错误响应
+func (o *MockServerOptions) misbehave(w http.ResponseWriter, r *http.Request) bool {
+	if d := o.latency.pick(); d > 0 {
+		select {
+		case <-time.After(d):
+		case <-r.Context().Done():
+			return true
+		}
+	}
+	if o.ErrorRate <= 0 || rand.Float64() >= o.ErrorRate {
+		return false
+	}
+	if rand.IntN(2) == 0 {
+		http.Error(w, "injected server error", http.StatusInternalServerError)
+	} else {
+		http.Error(w, `{"Status": {"Code": "9", "Message": "injected error"}}`, http.StatusOK)
+	}
+	return true
+}
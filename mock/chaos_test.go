@@ -0,0 +1,31 @@
+package mock
+
+import "testing"
+
+func TestParseLatency(t *testing.T) {
+	cases := []struct {
+		in      string
+		want    latencyRange
+		wantErr bool
+	}{
+		{"", latencyRange{}, false},
+		{"200ms", latencyRange{min: 200e6, max: 200e6}, false},
+		{"100ms - 1s", latencyRange{min: 100e6, max: 1e9}, false},
+		{"1s-100ms", latencyRange{}, true},
+		{"fast", latencyRange{}, true},
+		{"100ms-", latencyRange{}, true},
+	}
+	for _, c := range cases {
+		got, err := parseLatency(c.in)
+		if (err != nil) != c.wantErr || got != c.want {
+			t.Errorf("parseLatency(%q) = %v, %v; want %v, error %v", c.in, got, err, c.want, c.wantErr)
+		}
+	}
+
+	r := latencyRange{min: 10, max: 20}
+	for range 100 {
+		if d := r.pick(); d < r.min || d > r.max {
+			t.Fatalf("pick() = %v, outside [%v, %v]", d, r.min, r.max)
+		}
+	}
+}
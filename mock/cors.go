@@ -0,0 +1,73 @@
+package mock
+
+import (
+	"net/http"
+	"strings"
+)
+
+// routeTable 记录注册到mux的路由接受的请求方法，CORS预检据此返回允许的方法
+type routeTable struct {
+	mux     *http.ServeMux
+	methods map[string][]string // 路由模式 -> 方法
+}
+
+func newRouteTable() *routeTable {
+	return &routeTable{mux: http.NewServeMux(), methods: make(map[string][]string)}
+}
+
+// handle 注册路由及其接受的方法，方法的校验仍由处理函数自己完成
+func (t *routeTable) handle(pattern string, handler http.HandlerFunc, methods ...string) {
+	t.mux.HandleFunc(pattern, handler)
+	t.methods[pattern] = methods
+}
+
+// allowedMethods 返回请求路径对应路由接受的方法，未通过handle注册的路径返回nil
+func (t *routeTable) allowedMethods(r *http.Request) []string {
+	_, pattern := t.mux.Handler(r)
+	return t.methods[pattern]
+}
+
+// corsMiddleware 为匹配的Origin添加CORS头，并以204应答OPTIONS预检请求；
+// origins为*时允许所有来源，否则为逗号分隔的来源列表，只回显匹配的Origin。
+// 预检的允许方法取自请求路径对应的路由，允许的请求头回显预检请求中的Access-Control-Request-Headers，
+// Origin不匹配时不返回任何CORS头
+func corsMiddleware(origins string, routes *routeTable, next http.Handler) http.Handler {
+	allowed := strings.Split(origins, ",")
+	for i := range allowed {
+		allowed[i] = strings.TrimSpace(allowed[i])
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origins != "*" {
+			w.Header().Add("Vary", "Origin")
+		}
+		matched := false
+		for _, a := range allowed {
+			if a == "*" {
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+				matched = true
+				break
+			}
+			if a == origin {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+				matched = true
+				break
+			}
+		}
+
+		if r.Method != http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", "Access-Control-Request-Method")
+		w.Header().Add("Vary", "Access-Control-Request-Headers")
+		if methods := routes.allowedMethods(r); matched && len(methods) > 0 {
+			w.Header().Set("Access-Control-Allow-Methods", strings.Join(methods, ", ")+", "+http.MethodOptions)
+			if headers := r.Header.Get("Access-Control-Request-Headers"); headers != "" {
+				w.Header().Set("Access-Control-Allow-Headers", headers)
+			}
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
@@ -0,0 +1,78 @@
+package mock
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func testCORSHandler(origins string) http.Handler {
+	routes := newRouteTable()
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	routes.handle("/api/mock/query/{rs}", ok, http.MethodGet, http.MethodPost)
+	routes.handle("/api/mock/reload", ok, http.MethodPost)
+	return corsMiddleware(origins, routes, routes.mux)
+}
+
+func TestCORSPreflight(t *testing.T) {
+	cases := []struct {
+		name        string
+		origins     string
+		origin      string
+		path        string
+		wantOrigin  string
+		wantMethods string
+		wantHeaders string
+	}{
+		{"any origin", "*", "https://a.test", "/api/mock/query/users", "*", "GET, POST, OPTIONS", "Content-Type, Authorization"},
+		{"listed origin", "https://a.test, https://b.test", "https://b.test", "/api/mock/reload", "https://b.test", "POST, OPTIONS", "Content-Type, Authorization"},
+		{"unlisted origin", "https://a.test", "https://evil.test", "/api/mock/query/users", "", "", ""},
+		{"unknown route", "*", "https://a.test", "/nope", "*", "", ""},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodOptions, c.path, nil)
+			req.Header.Set("Origin", c.origin)
+			req.Header.Set("Access-Control-Request-Method", "POST")
+			req.Header.Set("Access-Control-Request-Headers", "Content-Type, Authorization")
+			rec := httptest.NewRecorder()
+			testCORSHandler(c.origins).ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusNoContent {
+				t.Errorf("status = %d, want 204", rec.Code)
+			}
+			h := rec.Header()
+			if got := h.Get("Access-Control-Allow-Origin"); got != c.wantOrigin {
+				t.Errorf("Allow-Origin = %q, want %q", got, c.wantOrigin)
+			}
+			if got := h.Get("Access-Control-Allow-Methods"); got != c.wantMethods {
+				t.Errorf("Allow-Methods = %q, want %q", got, c.wantMethods)
+			}
+			if got := h.Get("Access-Control-Allow-Headers"); got != c.wantHeaders {
+				t.Errorf("Allow-Headers = %q, want %q", got, c.wantHeaders)
+			}
+		})
+	}
+}
+
+func TestCORSSimpleRequest(t *testing.T) {
+	h := testCORSHandler("https://a.test")
+	for origin, want := range map[string]string{"https://a.test": "https://a.test", "https://evil.test": ""} {
+		req := httptest.NewRequest(http.MethodGet, "/api/mock/query/users", nil)
+		req.Header.Set("Origin", origin)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Errorf("%s: status = %d, want 200", origin, rec.Code)
+		}
+		if got := rec.Header().Get("Access-Control-Allow-Origin"); got != want {
+			t.Errorf("%s: Allow-Origin = %q, want %q", origin, got, want)
+		}
+		if got := rec.Header().Get("Access-Control-Allow-Methods"); got != "" {
+			t.Errorf("%s: Allow-Methods on a simple request: %q", origin, got)
+		}
+		if got := rec.Header().Get("Vary"); got != "Origin" {
+			t.Errorf("%s: Vary = %q, want Origin", origin, got)
+		}
+	}
+}
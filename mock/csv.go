@@ -0,0 +1,138 @@
+package mock
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// readCSV 读取CSV文件并校验表头与每行字段数，返回的第一行为表头。
+// 字段数与表头不一致的行在skipBad为true时跳过并打印警告，否则返回包含文件名和行号的错误
+func readCSV(fileName string, skipBad bool) ([][]string, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1 // 字段数由下方自行校验
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%s: empty file, a header row is required", fileName)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fileName, err)
+	}
+
+	records := [][]string{header}
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", fileName, err)
+		}
+		if len(record) != len(header) {
+			line, _ := reader.FieldPos(0)
+			if !skipBad {
+				return nil, fmt.Errorf("%s:%d: expected %d fields, got %d", fileName, line, len(header), len(record))
+			}
+			fmt.Printf("skipping %s:%d: expected %d fields, got %d\n", fileName, line, len(header), len(record))
+			continue
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// csvColumn 描述CSV列名及其值类型，类型可由表头注解（如 id:int）指定或从数据推断
+type csvColumn struct {
+	name string
+	typ  string // int, float, bool, string；为空时需推断
+}
+
+// parseCSVHeader 解析表头，支持 name:type 形式的类型注解
+func parseCSVHeader(header []string) []csvColumn {
+	columns := make([]csvColumn, len(header))
+	for i, h := range header {
+		name, typ := h, ""
+		if idx := strings.LastIndex(h, ":"); idx > 0 {
+			switch t := strings.ToLower(strings.TrimSpace(h[idx+1:])); t {
+			case "int", "float", "bool", "string":
+				name, typ = strings.TrimSpace(h[:idx]), t
+			}
+		}
+		columns[i] = csvColumn{name: name, typ: typ}
+	}
+	return columns
+}
+
+// inferColumnType 根据列中所有非空值推断类型：int、float、bool，否则为string
+func inferColumnType(values []string) string {
+	isInt, isFloat, isBool := true, true, true
+	seen := false
+	for _, v := range values {
+		if v == "" {
+			continue
+		}
+		seen = true
+		if isInt {
+			if _, err := strconv.ParseInt(v, 10, 64); err != nil {
+				isInt = false
+			}
+		}
+		if isFloat {
+			if _, err := strconv.ParseFloat(v, 64); err != nil {
+				isFloat = false
+			}
+		}
+		if isBool {
+			if l := strings.ToLower(v); l != "true" && l != "false" {
+				isBool = false
+			}
+		}
+	}
+	switch {
+	case !seen:
+		return "string"
+	case isInt:
+		return "int"
+	case isFloat:
+		return "float"
+	case isBool:
+		return "bool"
+	}
+	return "string"
+}
+
+// convertCSVValue 将单元格转换为列类型，空值转换为nil（JSON null）
+func convertCSVValue(v, typ string) interface{} {
+	if typ == "string" {
+		return v
+	}
+	if v == "" || strings.EqualFold(v, "null") {
+		return nil
+	}
+	switch typ {
+	case "int":
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return n
+		}
+	case "float":
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f
+		}
+	case "bool":
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+	return v
+}
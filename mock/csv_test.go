@@ -0,0 +1,71 @@
+package mock
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestInferColumnType(t *testing.T) {
+	cases := []struct {
+		values []string
+		want   string
+	}{
+		{[]string{"1", "-2", ""}, "int"},
+		{[]string{"1", "2.5"}, "float"},
+		{[]string{"true", "FALSE", ""}, "bool"},
+		{[]string{"1", "a"}, "string"},
+		{[]string{"", ""}, "string"},
+		{[]string{"0", "1", "true"}, "string"},
+	}
+	for _, c := range cases {
+		if got := inferColumnType(c.values); got != c.want {
+			t.Errorf("inferColumnType(%q) = %s, want %s", c.values, got, c.want)
+		}
+	}
+}
+
+func TestLoadCSVInfersTypes(t *testing.T) {
+	file := writeDataFile(t, "people.csv", "id,score,active,name,zip:string\n1,1.5,true,a,01234\n2,,false,b,02134\n")
+	into := map[string][]interface{}{}
+	o := &MockServerOptions{BadRows: "error"}
+	if err := o.loadCSV(file, into); err != nil {
+		t.Fatalf("loadCSV: %v", err)
+	}
+	want := []interface{}{
+		map[string]interface{}{"id": int64(1), "score": 1.5, "active": true, "name": "a", "zip": "01234"},
+		map[string]interface{}{"id": int64(2), "score": nil, "active": false, "name": "b", "zip": "02134"},
+	}
+	if got := into["people"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	o.NoInfer = true
+	if err := o.loadCSV(file, into); err != nil {
+		t.Fatalf("loadCSV: %v", err)
+	}
+	if id := recordField(into["people"][0], "id"); id != "1" {
+		t.Errorf("--no-infer: id = %#v, want \"1\"", id)
+	}
+}
+
+func TestReadCSVMalformedRows(t *testing.T) {
+	file := writeDataFile(t, "bad.csv", "id,name\n1,a\n2\n3,c,extra\n4,d\n")
+
+	_, err := readCSV(file, false)
+	if err == nil || !strings.Contains(err.Error(), "bad.csv:3: expected 2 fields, got 1") {
+		t.Errorf("error = %v, want the file, line and field counts", err)
+	}
+
+	records, err := readCSV(file, true)
+	if err != nil {
+		t.Fatalf("skip: %v", err)
+	}
+	if len(records) != 3 || records[1][0] != "1" || records[2][0] != "4" {
+		t.Errorf("skip: got %q, want the header and rows 1 and 4", records)
+	}
+
+	if _, err := readCSV(writeDataFile(t, "empty.csv", ""), false); err == nil {
+		t.Error("expected an error for an empty file")
+	}
+}
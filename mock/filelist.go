@@ -0,0 +1,135 @@
+package mock
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// storedFile 文件列表中的一项，Name为相对LocalDir的路径（使用/分隔）
+type storedFile struct {
+	Name    string    `json:"name"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+}
+
+// isUploadTemp 判断是否为stageFile写入的临时文件
+func isUploadTemp(name string) bool {
+	return strings.HasPrefix(name, ".") && strings.HasSuffix(name, ".tmp")
+}
+
+// listFiles 列出LocalDir中的文件，recursive为false时不进入子目录
+func (o FileServerOptions) listFiles(recursive bool) ([]storedFile, error) {
+	files := []storedFile{}
+	err := filepath.WalkDir(o.LocalDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != o.LocalDir && !recursive {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || isUploadTemp(d.Name()) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(o.LocalDir, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, storedFile{Name: filepath.ToSlash(rel), Size: info.Size(), ModTime: info.ModTime()})
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+	return files, nil
+}
+
+// filesHandler GET列出文件，DELETE（需--allow-purge）清空LocalDir
+func (o FileServerOptions) filesHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case http.MethodGet:
+		o.listHandler(w, r)
+	case http.MethodDelete:
+		o.purgeHandler(w, r)
+	default:
+		fileError(w, http.StatusOK, "GET or DELETE method only")
+	}
+}
+
+// listHandler 以JSON数组返回LocalDir中的文件，?recursive=true时包含子目录中的文件
+func (o FileServerOptions) listHandler(w http.ResponseWriter, r *http.Request) {
+
+	files, err := o.listFiles(r.URL.Query().Get("recursive") == "true")
+	if err != nil {
+		fileError(w, http.StatusOK, "list files failed: %v", err)
+		return
+	}
+	json.NewEncoder(w).Encode(files)
+}
+
+// deleteHandler 删除LocalDir中的单个文件，文件名只取基本名，不能删除其他目录中的文件
+func (o FileServerOptions) deleteHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodDelete {
+		fileError(w, http.StatusOK, "DELETE method only")
+		return
+	}
+
+	name := filepath.Base(r.PathValue("name"))
+	if name == "." || name == "/" || name == ".." {
+		fileError(w, http.StatusOK, "invalid file name")
+		return
+	}
+	dstPath := filepath.Join(o.LocalDir, name)
+	if info, err := os.Stat(dstPath); err != nil || info.IsDir() {
+		fileError(w, http.StatusOK, "file not found")
+		return
+	}
+	if err := os.Remove(dstPath); err != nil {
+		fileError(w, http.StatusOK, "delete file failed: %v", err)
+		return
+	}
+
+	o.logRequest(r, "file deleted", "file", name)
+	fmt.Fprint(w, `{"code": "1", "msg": "OK"}`)
+}
+
+// purgeHandler 删除LocalDir中的全部内容，保留目录本身
+func (o FileServerOptions) purgeHandler(w http.ResponseWriter, r *http.Request) {
+	if !o.AllowPurge {
+		fileError(w, http.StatusOK, "purge is disabled, start the server with --allow-purge")
+		return
+	}
+
+	entries, err := os.ReadDir(o.LocalDir)
+	if err != nil && !os.IsNotExist(err) {
+		fileError(w, http.StatusOK, "list files failed: %v", err)
+		return
+	}
+	for _, entry := range entries {
+		if err := os.RemoveAll(filepath.Join(o.LocalDir, entry.Name())); err != nil {
+			fileError(w, http.StatusOK, "delete %s failed: %v", entry.Name(), err)
+			return
+		}
+	}
+
+	o.logRequest(r, "files purged", "dir", o.LocalDir, "deleted", len(entries))
+	fmt.Fprintf(w, `{"code": "1", "msg": "OK", "deleted": %d}`, len(entries))
+}
@@ -0,0 +1,40 @@
+package mock
+
+import (
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+)
+
+// newFileLogger 创建文件服务的结构化日志，jsonLines为true时每条日志输出一行JSON，便于日志系统采集
+func newFileLogger(jsonLines bool) *slog.Logger {
+	if jsonLines {
+		return slog.New(slog.NewJSONHandler(os.Stderr, nil))
+	}
+	return slog.New(slog.NewTextHandler(os.Stderr, nil))
+}
+
+// logger 返回Run中创建的日志，直接调用处理函数（如测试中）时使用默认日志
+func (o FileServerOptions) logger() *slog.Logger {
+	if o.log == nil {
+		return slog.Default()
+	}
+	return o.log
+}
+
+// logRequest 输出带请求方法和客户端地址的日志
+func (o FileServerOptions) logRequest(r *http.Request, msg string, attrs ...any) {
+	attrs = append([]any{"method", r.Method, "remote", r.RemoteAddr}, attrs...)
+	o.logger().Info(msg, attrs...)
+}
+
+// accessLog 为每个请求记录一条访问日志：方法、路径、客户端地址、状态码和耗时
+func (o FileServerOptions) accessLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		o.logRequest(r, "request", "path", r.URL.Path, "status", rec.status, "duration", time.Since(start))
+	})
+}
@@ -1,12 +1,22 @@
 package mock
 
 import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io"
-	"log"
+	"math/rand/v2"
+	"mime"
+	"mime/multipart"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
+	"time"
 )
 
 func (o FileServerOptions) Run() error {
@@ -15,17 +25,87 @@ func (o FileServerOptions) Run() error {
 		return fmt.Errorf("create local directory failed: %v", err)
 	}
 
-	http.HandleFunc("/api/mock/file", o.uploadHandler)
-	http.HandleFunc("/api/mock/file-error/unknown-fields", o.uploadUnknownHandler)
-	http.HandleFunc("/api/mock/file-error/missing-fields", o.uploadMissingHandler)
+	o.log = newFileLogger(o.LogJson)
+	if o.Metrics {
+		o.metrics = newHTTPMetrics()
+	}
+
+	tlsConfig, fingerprint, err := o.tlsConfig()
+	if err != nil {
+		return err
+	}
 
-	fmt.Printf("Server listening at :%d\n", o.Port)
-	if err := http.ListenAndServe(fmt.Sprintf(":%d", o.Port), nil); err != nil {
+	server := &http.Server{
+		Addr:      fmt.Sprintf(":%d", o.Port),
+		Handler:   o.accessLog(instrument(o.Handler(), o.metrics, o.MetricsPort)),
+		TLSConfig: tlsConfig,
+	}
+	if tlsConfig == nil {
+		fmt.Printf("Server listening at :%d%s/file\n", o.Port, o.basePath())
+		err = server.ListenAndServe()
+	} else {
+		fmt.Printf("Server listening at :%d%s/file (HTTPS)\n", o.Port, o.basePath())
+		fmt.Printf("Certificate SHA-256 fingerprint: %s\n", fingerprint)
+		// 证书已在TLSConfig中，文件参数留空
+		err = server.ListenAndServeTLS("", "")
+	}
+	if err != nil {
 		return fmt.Errorf("server listen failed: %v", err)
 	}
 	return nil
 }
 
+// Handler 返回挂载在BasePath下的文件服务路由，使用独立的ServeMux，便于在同一进程中嵌入多个实例
+func (o FileServerOptions) Handler() *http.ServeMux {
+	base := o.basePath()
+	mux := http.NewServeMux()
+	mux.HandleFunc(base+"/file", o.requireToken(o.uploadHandler))
+	mux.HandleFunc(base+"/file/{name}", o.requireToken(o.deleteHandler))
+	mux.HandleFunc(base+"/files", o.requireToken(o.filesHandler))
+	mux.HandleFunc(base+"/file-error/unknown-fields", o.uploadUnknownHandler)
+	mux.HandleFunc(base+"/file-error/missing-fields", o.uploadMissingHandler)
+	return mux
+}
+
+// basePath 规范化BasePath：以/开头，不以/结尾，空值表示根路径
+func (o FileServerOptions) basePath() string {
+	base := strings.Trim(o.BasePath, "/")
+	if base == "" {
+		return ""
+	}
+	return "/" + base
+}
+
+// requireToken 配置了Token时要求请求携带Authorization: Bearer <token>，否则返回401；
+// 未配置时不做校验
+func (o FileServerOptions) requireToken(next http.HandlerFunc) http.HandlerFunc {
+	if o.Token == "" {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(o.Token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="file-server"`)
+			fileError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// methodAllowed 判断请求方法是否在Methods中，未配置时只接受POST
+func (o FileServerOptions) methodAllowed(method string) bool {
+	if len(o.Methods) == 0 {
+		return method == http.MethodPost
+	}
+	for _, m := range o.Methods {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+	return false
+}
+
 func (o FileServerOptions) uploadUnknownHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
@@ -47,48 +127,286 @@ func (o FileServerOptions) uploadMissingHandler(w http.ResponseWriter, r *http.R
 func (o FileServerOptions) uploadHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	if r.Method != http.MethodPost {
-		http.Error(w, `{"code": "0", "msg": "POST method only"}`, http.StatusOK)
+	if !o.methodAllowed(r.Method) {
+		allowed := "POST"
+		if len(o.Methods) > 0 {
+			allowed = strings.ToUpper(strings.Join(o.Methods, "/"))
+		}
+		fileError(w, http.StatusOK, "%s method only", allowed)
 		return
 	}
 
 	r.Body = http.MaxBytesReader(w, r.Body, o.MaxFileSize*1024*1024)
 
 	if err := r.ParseMultipartForm(o.MaxFileSize * 1024 * 1024); err != nil {
-		http.Error(w, fmt.Sprintf(`{"code": "0", "msg": "request body too large: %v"}`, err), http.StatusOK)
+		fileError(w, http.StatusOK, "request body too large: %v", err)
 		return
 	}
 
-	file, header, err := r.FormFile(o.FormKey)
-	if err != nil {
-		http.Error(w, fmt.Sprintf(`{"code": "0", "msg": "no files in request: %v"}`, err), http.StatusOK)
+	headers := r.MultipartForm.File[o.FormKey]
+	if len(headers) == 0 {
+		fileError(w, http.StatusOK, "no files in request: %v", http.ErrMissingFile)
 		return
 	}
+
+	// 先检查全部文件的类型，有一个不允许就整个请求都不写入磁盘
+	for _, header := range headers {
+		if !o.typeAllowed(header) {
+			fileError(w, http.StatusOK, "unsupported type")
+			return
+		}
+	}
+
+	// 全部文件先写入临时文件，都成功后再重命名到目标位置，任一文件失败时整个请求不留下文件
+	staged := make([]stagedUpload, 0, len(headers))
+	defer func() {
+		for _, st := range staged {
+			os.Remove(st.tmpPath)
+		}
+	}()
+	for _, header := range headers {
+		start := time.Now()
+		st, err := o.stageUpload(r.Context(), header)
+		if err != nil {
+			o.logRequest(r, "upload failed", "file", header.Filename, "error", err)
+			fileError(w, http.StatusOK, "%v", err)
+			return
+		}
+		st.duration = time.Since(start)
+		staged = append(staged, st)
+	}
+	for i, st := range staged {
+		if err := os.Rename(st.tmpPath, st.dstPath); err != nil {
+			for _, done := range staged[:i] {
+				os.Remove(done.dstPath)
+			}
+			o.logRequest(r, "upload failed", "file", st.Name, "error", err)
+			fileError(w, http.StatusOK, "store file failed: %v", err)
+			return
+		}
+	}
+
+	resp := uploadResponse{Code: "1", Msg: "OK", Files: make([]uploadedFile, 0, len(staged))}
+	for _, st := range staged {
+		o.metrics.addUploadBytes(st.Size)
+		o.logRequest(r, "file uploaded", "file", st.Name, "size", st.Size, "sha256", st.SHA256, "duration", st.duration)
+		resp.Files = append(resp.Files, st.uploadedFile)
+		resp.Size += st.Size
+	}
+	// 只上传一个文件时保持原有的sha256字段
+	if len(resp.Files) == 1 {
+		resp.SHA256 = resp.Files[0].SHA256
+	}
+
+	if delay := o.responseDelay(); delay > 0 {
+		// 客户端断开后不再等待
+		select {
+		case <-r.Context().Done():
+			return
+		case <-time.After(delay):
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// typeAllowed 判断上传文件是否在AllowedTypes中，未配置时不限制；
+// 以.开头的项按扩展名匹配，含/的项按文件前512字节检测出的MIME类型匹配，支持image/*形式
+func (o FileServerOptions) typeAllowed(header *multipart.FileHeader) bool {
+	if len(o.AllowedTypes) == 0 {
+		return true
+	}
+	ext := strings.ToLower(filepath.Ext(header.Filename))
+	detected := ""
+	for _, t := range o.AllowedTypes {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if !strings.Contains(t, "/") {
+			if ext != "" && strings.TrimPrefix(t, ".") == ext[1:] {
+				return true
+			}
+			continue
+		}
+		if detected == "" {
+			detected = detectContentType(header)
+		}
+		if t == detected || (strings.HasSuffix(t, "/*") && strings.HasPrefix(detected, strings.TrimSuffix(t, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+// detectContentType 用http.DetectContentType检测文件前512字节，返回不带参数的MIME类型
+func detectContentType(header *multipart.FileHeader) string {
+	file, err := header.Open()
+	if err != nil {
+		return ""
+	}
 	defer file.Close()
 
+	buf := make([]byte, 512)
+	n, _ := io.ReadFull(file, buf)
+	mediaType, _, err := mime.ParseMediaType(http.DetectContentType(buf[:n]))
+	if err != nil {
+		return ""
+	}
+	return mediaType
+}
+
+// fileResult 文件服务错误响应的格式
+type fileResult struct {
+	Code string `json:"code"`
+	Msg  string `json:"msg"`
+}
+
+// fileError 返回{"code": "0", "msg": ...}形式的错误，msg经JSON编码，可以包含客户端提供的文件名
+func fileError(w http.ResponseWriter, status int, format string, args ...any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(fileResult{Code: "0", Msg: fmt.Sprintf(format, args...)})
+}
+
+// uploadedFile 单个已保存文件的摘要
+type uploadedFile struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// uploadResponse 上传成功的响应，Size为本次请求保存的总字节数
+type uploadResponse struct {
+	Code   string         `json:"code"`
+	Msg    string         `json:"msg"`
+	Size   int64          `json:"size"`
+	SHA256 string         `json:"sha256,omitempty"`
+	Files  []uploadedFile `json:"files"`
+}
+
+// stagedUpload 已写入临时文件、等待重命名到dstPath的上传文件
+type stagedUpload struct {
+	uploadedFile
+	tmpPath  string
+	dstPath  string
+	duration time.Duration
+}
+
+// stageUpload 将表单中的一个文件写入LocalDir中目标位置旁的临时文件，返回的错误信息可直接用于响应
+func (o FileServerOptions) stageUpload(ctx context.Context, header *multipart.FileHeader) (stagedUpload, error) {
 	if header.Filename == "" {
-		http.Error(w, `{"code": "0", "msg": "invalid file name"}`, http.StatusOK)
-		return
+		return stagedUpload{}, fmt.Errorf("invalid file name")
+	}
+	file, err := header.Open()
+	if err != nil {
+		return stagedUpload{}, fmt.Errorf("open upload failed: %v", err)
+	}
+	defer file.Close()
+
+	name := filepath.Base(header.Filename)
+	if o.PreservePaths {
+		if name, err = uploadRelPath(header); err != nil {
+			return stagedUpload{}, err
+		}
+	}
+	dstPath := filepath.Join(o.LocalDir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(dstPath), os.ModePerm); err != nil {
+		return stagedUpload{}, fmt.Errorf("create directory failed: %v", err)
 	}
 
-	dstPath := filepath.Join(o.LocalDir, filepath.Base(header.Filename))
-	dstFile, err := os.Create(dstPath)
+	var src io.Reader = file
+	if o.Throttle > 0 {
+		src = &throttledReader{ctx: ctx, r: file, rate: o.Throttle, start: time.Now()}
+	}
+	tmpPath, written, checksum, err := stageFile(dstPath, src)
 	if err != nil {
-		http.Error(w, fmt.Sprintf(`{"code": "0", "msg": "create file failed: %v"}`, err), http.StatusOK)
-		return
+		return stagedUpload{}, fmt.Errorf("store file failed: %v", err)
 	}
-	defer dstFile.Close()
+	return stagedUpload{
+		uploadedFile: uploadedFile{Name: name, Size: written, SHA256: checksum},
+		tmpPath:      tmpPath,
+		dstPath:      dstPath,
+	}, nil
+}
 
-	if _, err := io.Copy(dstFile, file); err != nil {
-		http.Error(w, fmt.Sprintf(`{"code": "0", "msg": "store file failed: %v"}`, err), http.StatusOK)
-		return
+// uploadRelPath 从Content-Disposition中取出客户端提供的原始文件名（header.Filename已被截取为基本名），
+// 统一为/分隔的相对路径，包含..的路径视为目录穿越而拒绝
+func uploadRelPath(header *multipart.FileHeader) (string, error) {
+	_, params, err := mime.ParseMediaType(header.Header.Get("Content-Disposition"))
+	raw := params["filename"]
+	if err != nil || raw == "" {
+		raw = header.Filename
+	}
+	raw = strings.ReplaceAll(raw, "\\", "/")
+	for _, seg := range strings.Split(raw, "/") {
+		if seg == ".." {
+			return "", fmt.Errorf("invalid file name %s: parent directory references are not allowed", raw)
+		}
 	}
+	// 去掉盘符和开头的/，使路径相对于LocalDir
+	if len(raw) >= 2 && raw[1] == ':' {
+		raw = raw[2:]
+	}
+	rel := strings.TrimLeft(path.Clean("/"+raw), "/")
+	if rel == "" {
+		return "", fmt.Errorf("invalid file name")
+	}
+	return rel, nil
+}
 
-	log.Printf("File uploaded: %s", dstPath)
+// stageFile 写入dstPath同目录下的临时文件，由调用方重命名为dstPath或删除，
+// 避免中途出错留下看似完整的截断文件；返回临时文件路径、写入的字节数和SHA256
+func stageFile(dstPath string, src io.Reader) (string, int64, string, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(dstPath), "."+filepath.Base(dstPath)+".*.tmp")
+	if err != nil {
+		return "", 0, "", err
+	}
 
-	w.WriteHeader(http.StatusOK)
-	fmt.Fprintf(w, `{
-        "code": "1",
-        "msg": "OK"
-    }`)
+	hash := sha256.New()
+	written, err := io.Copy(io.MultiWriter(tmp, hash), src)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return "", written, "", err
+	}
+	return tmp.Name(), written, hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// responseDelay 返回上传成功后响应前的等待时间：固定延迟加随机抖动
+func (o FileServerOptions) responseDelay() time.Duration {
+	delay := o.UploadDelay
+	if o.DelayJitter > 0 {
+		delay += rand.N(o.DelayJitter)
+	}
+	return delay
+}
+
+// throttledReader 将读取速度限制在每秒rate字节以内，ctx结束时停止等待并返回错误
+type throttledReader struct {
+	ctx   context.Context
+	r     io.Reader
+	rate  int64
+	start time.Time
+	read  int64
+}
+
+func (t *throttledReader) Read(p []byte) (int, error) {
+	// 单次读取不超过每秒限额，使等待粒度保持在1秒以内
+	if int64(len(p)) > t.rate {
+		p = p[:t.rate]
+	}
+	n, err := t.r.Read(p)
+	t.read += int64(n)
+
+	expected := time.Duration(float64(t.read) / float64(t.rate) * float64(time.Second))
+	if wait := expected - time.Since(t.start); wait > 0 {
+		select {
+		case <-t.ctx.Done():
+			return n, t.ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+	return n, err
 }
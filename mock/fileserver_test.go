@@ -0,0 +1,352 @@
+package mock
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// uploadFile 为一个文件构造上传请求，文件名原样写入Content-Disposition
+func uploadFile(t *testing.T, url, key, filename, content string) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	h := textproto.MIMEHeader{}
+	h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, key, filename))
+	part, err := mw.CreatePart(h)
+	if err != nil {
+		t.Fatalf("CreatePart: %v", err)
+	}
+	part.Write([]byte(content))
+	mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, url, &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+// serve 执行请求并将响应解析为JSON对象
+func serve(t *testing.T, h http.Handler, req *http.Request) (int, map[string]any) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	var resp map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("response is not valid JSON: %v\n%s", err, rec.Body.String())
+	}
+	return rec.Code, resp
+}
+
+func TestFileErrorEscapesFileName(t *testing.T) {
+	o := FileServerOptions{LocalDir: t.TempDir(), FormKey: "files", MaxFileSize: 1, PreservePaths: true}
+	req := uploadFile(t, "/file", "files", `../x\"}, \"code\": \"1`, "data")
+
+	_, resp := serve(t, o.Handler(), req)
+	if resp["code"] != "0" {
+		t.Fatalf("code = %v, want 0", resp["code"])
+	}
+	if msg, _ := resp["msg"].(string); !strings.Contains(msg, `x"}, "code": "1`) {
+		t.Errorf("msg = %q, want the raw file name", msg)
+	}
+}
+
+func TestMetricsArePerServer(t *testing.T) {
+	newServer := func() (FileServerOptions, http.Handler) {
+		o := FileServerOptions{LocalDir: t.TempDir(), FormKey: "files", MaxFileSize: 1, metrics: newHTTPMetrics()}
+		return o, instrument(o.Handler(), o.metrics, 0)
+	}
+	_, a := newServer()
+	_, b := newServer()
+
+	serve(t, a, uploadFile(t, "/file", "files", "a.txt", "12345"))
+	serve(t, b, uploadFile(t, "/file", "files", "b.txt", "12"))
+	serve(t, b, uploadFile(t, "/file", "files", "c.txt", "1"))
+
+	for _, c := range []struct {
+		h     http.Handler
+		bytes string
+		reqs  string
+	}{{a, "5", "1"}, {b, "3", "2"}} {
+		rec := httptest.NewRecorder()
+		c.h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		body := rec.Body.String()
+		if !strings.Contains(body, "mock_upload_bytes_total "+c.bytes+"\n") {
+			t.Errorf("want mock_upload_bytes_total %s in:\n%s", c.bytes, body)
+		}
+		if !strings.Contains(body, `mock_http_requests_total{method="POST",path="/file",status="200"} `+c.reqs+"\n") {
+			t.Errorf("want %s POST /file requests in:\n%s", c.reqs, body)
+		}
+	}
+}
+
+func TestUploadReportsChecksums(t *testing.T) {
+	o := FileServerOptions{LocalDir: t.TempDir(), FormKey: "files", MaxFileSize: 1}
+	sum := func(content string) string {
+		h := sha256.Sum256([]byte(content))
+		return hex.EncodeToString(h[:])
+	}
+
+	// 单个文件同时给出顶层的sha256
+	status, resp := serve(t, o.Handler(), uploadFile(t, "/file", "files", "a.txt", "hello"))
+	if status != http.StatusOK || resp["size"] != float64(5) || resp["sha256"] != sum("hello") {
+		t.Fatalf("single upload: %d %v", status, resp)
+	}
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for _, f := range []struct{ name, content string }{{"b.txt", "foo"}, {"c.txt", "barbaz"}} {
+		part, err := mw.CreateFormFile("files", f.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		part.Write([]byte(f.content))
+	}
+	mw.Close()
+	req := httptest.NewRequest(http.MethodPost, "/file", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+
+	status, resp = serve(t, o.Handler(), req)
+	if status != http.StatusOK || resp["size"] != float64(9) || resp["sha256"] != nil {
+		t.Fatalf("multi upload: %d %v", status, resp)
+	}
+	files, _ := resp["files"].([]any)
+	want := []map[string]any{
+		{"name": "b.txt", "size": float64(3), "sha256": sum("foo")},
+		{"name": "c.txt", "size": float64(6), "sha256": sum("barbaz")},
+	}
+	if len(files) != len(want) {
+		t.Fatalf("files = %v", files)
+	}
+	for i, f := range files {
+		if !reflect.DeepEqual(f, want[i]) {
+			t.Errorf("files[%d] = %v, want %v", i, f, want[i])
+		}
+	}
+}
+
+func TestUploadRelPath(t *testing.T) {
+	cases := []struct {
+		filename string
+		want     string
+		wantErr  bool
+	}{
+		{"a.txt", "a.txt", false},
+		{"images/logo.png", "images/logo.png", false},
+		{`images\\win\\logo.png`, "images/win/logo.png", false},
+		{"/etc/passwd", "etc/passwd", false},
+		{`C:\\Users\\x.txt`, "Users/x.txt", false},
+		{"a/./b//c.txt", "a/b/c.txt", false},
+		{"../secret", "", true},
+		{"a/../../secret", "", true},
+		{`..\\secret`, "", true},
+		{"/", "", true},
+	}
+	for _, c := range cases {
+		req := uploadFile(t, "/file", "files", c.filename, "x")
+		if err := req.ParseMultipartForm(1 << 20); err != nil {
+			t.Fatalf("ParseMultipartForm: %v", err)
+		}
+		got, err := uploadRelPath(req.MultipartForm.File["files"][0])
+		if (err != nil) != c.wantErr || got != c.want {
+			t.Errorf("uploadRelPath(%q) = %q, %v; want %q, error %v", c.filename, got, err, c.want, c.wantErr)
+		}
+	}
+}
+
+func TestUploadPreservePathsStaysInLocalDir(t *testing.T) {
+	dir := t.TempDir()
+	o := FileServerOptions{LocalDir: dir, FormKey: "files", MaxFileSize: 1, PreservePaths: true}
+
+	_, resp := serve(t, o.Handler(), uploadFile(t, "/file", "files", "/sub/dir/a.txt", "hello"))
+	if resp["code"] != "1" {
+		t.Fatalf("upload failed: %v", resp)
+	}
+	if b, err := os.ReadFile(filepath.Join(dir, "sub", "dir", "a.txt")); err != nil || string(b) != "hello" {
+		t.Errorf("stored file = %q, %v", b, err)
+	}
+
+	_, resp = serve(t, o.Handler(), uploadFile(t, "/file", "files", "../escape.txt", "x"))
+	if resp["code"] != "0" {
+		t.Errorf("traversal was accepted: %v", resp)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(dir), "escape.txt")); err == nil {
+		t.Error("file was written outside --local-dir")
+	}
+}
+
+// 多文件上传中后面的文件被拒绝时，前面的文件也不能留在LocalDir中
+func TestUploadMultiFailureKeepsNothing(t *testing.T) {
+	dir := t.TempDir()
+	o := FileServerOptions{LocalDir: dir, FormKey: "files", MaxFileSize: 1, PreservePaths: true}
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for _, name := range []string{"a.txt", "../escape.txt"} {
+		h := textproto.MIMEHeader{}
+		h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="files"; filename="%s"`, name))
+		part, err := mw.CreatePart(h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		part.Write([]byte("x"))
+	}
+	mw.Close()
+	req := httptest.NewRequest(http.MethodPost, "/file", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+
+	if _, resp := serve(t, o.Handler(), req); resp["code"] != "0" {
+		t.Fatalf("upload with a rejected file succeeded: %v", resp)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		t.Errorf("left in --local-dir: %s", e.Name())
+	}
+}
+
+// 客户端断开后，限速读取和响应延迟都不再继续等待
+func TestUploadStopsWaitingWhenClientGone(t *testing.T) {
+	cases := []struct {
+		name string
+		opts FileServerOptions
+	}{
+		{"throttle", FileServerOptions{Throttle: 1}},
+		{"upload delay", FileServerOptions{UploadDelay: time.Minute}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			o := c.opts
+			o.LocalDir, o.FormKey, o.MaxFileSize = t.TempDir(), "files", 1
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			req := uploadFile(t, "/file", "files", "a.txt", "0123456789").WithContext(ctx)
+
+			start := time.Now()
+			o.Handler().ServeHTTP(httptest.NewRecorder(), req)
+			if d := time.Since(start); d > 5*time.Second {
+				t.Errorf("handler returned after %s", d)
+			}
+		})
+	}
+}
+
+func TestUploadAllowedTypes(t *testing.T) {
+	png := "\x89PNG\r\n\x1a\n" + strings.Repeat("\x00", 16)
+	cases := []struct {
+		allowed  []string
+		filename string
+		content  string
+		want     string
+	}{
+		{nil, "a.exe", "MZ", "1"},
+		{[]string{".png"}, "a.PNG", "x", "1"},
+		{[]string{"png"}, "a.png", "x", "1"},
+		{[]string{".png"}, "a.txt", "x", "0"},
+		{[]string{"image/png"}, "renamed.txt", png, "1"},
+		{[]string{"image/*"}, "a.bin", png, "1"},
+		{[]string{"image/*"}, "a.png", "plain text", "0"},
+	}
+	for _, c := range cases {
+		o := FileServerOptions{LocalDir: t.TempDir(), FormKey: "files", MaxFileSize: 1, AllowedTypes: c.allowed}
+		_, resp := serve(t, o.Handler(), uploadFile(t, "/file", "files", c.filename, c.content))
+		if resp["code"] != c.want {
+			t.Errorf("allowed %v, file %s: code = %v, want %s (%v)", c.allowed, c.filename, resp["code"], c.want, resp["msg"])
+		}
+	}
+}
+
+func TestBearerToken(t *testing.T) {
+	o := FileServerOptions{LocalDir: t.TempDir(), FormKey: "files", MaxFileSize: 1, Token: "s3cret"}
+	cases := []struct {
+		auth string
+		want int
+	}{
+		{"", http.StatusUnauthorized},
+		{"Bearer wrong", http.StatusUnauthorized},
+		{"Basic s3cret", http.StatusUnauthorized},
+		{"Bearer s3cret", http.StatusOK},
+	}
+	for _, c := range cases {
+		for _, req := range []*http.Request{
+			uploadFile(t, "/file", "files", "a.txt", "x"),
+			httptest.NewRequest(http.MethodGet, "/files", nil),
+			httptest.NewRequest(http.MethodDelete, "/file/a.txt", nil),
+		} {
+			if c.auth != "" {
+				req.Header.Set("Authorization", c.auth)
+			}
+			rec := httptest.NewRecorder()
+			o.Handler().ServeHTTP(rec, req)
+			if rec.Code != c.want {
+				t.Errorf("%s %s with %q: status %d, want %d", req.Method, req.URL.Path, c.auth, rec.Code, c.want)
+			}
+			if c.want == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Errorf("%s %s: missing WWW-Authenticate", req.Method, req.URL.Path)
+			}
+		}
+	}
+}
+
+func TestDeleteAndPurge(t *testing.T) {
+	dir := t.TempDir()
+	outside := filepath.Join(filepath.Dir(dir), "outside.txt")
+	os.WriteFile(outside, []byte("keep"), 0o644)
+	defer os.Remove(outside)
+	for _, name := range []string{"a.txt", "b.txt"} {
+		os.WriteFile(filepath.Join(dir, name), []byte(name), 0o644)
+	}
+	os.MkdirAll(filepath.Join(dir, "sub"), 0o755)
+
+	o := FileServerOptions{LocalDir: dir}
+	h := o.Handler()
+	del := func(path string) map[string]any {
+		_, resp := serve(t, h, httptest.NewRequest(http.MethodDelete, path, nil))
+		return resp
+	}
+
+	if resp := del("/file/a.txt"); resp["code"] != "1" {
+		t.Errorf("delete a.txt: %v", resp)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "a.txt")); !os.IsNotExist(err) {
+		t.Error("a.txt still exists")
+	}
+	if resp := del("/file/a.txt"); resp["msg"] != "file not found" {
+		t.Errorf("delete missing file: %v", resp)
+	}
+	if resp := del("/file/sub"); resp["msg"] != "file not found" {
+		t.Errorf("delete directory: %v", resp)
+	}
+	if resp := del("/file/..%2Foutside.txt"); resp["code"] != "0" {
+		t.Errorf("delete outside --local-dir: %v", resp)
+	}
+	if _, err := os.Stat(outside); err != nil {
+		t.Errorf("file outside --local-dir was deleted: %v", err)
+	}
+
+	if resp := del("/files"); resp["code"] != "0" {
+		t.Errorf("purge without --allow-purge: %v", resp)
+	}
+	o.AllowPurge = true
+	h = o.Handler()
+	if resp := del("/files"); resp["code"] != "1" || resp["deleted"] != 2.0 {
+		t.Errorf("purge: %v", resp)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("purge left %d entries", len(entries))
+	}
+}
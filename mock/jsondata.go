@@ -0,0 +1,88 @@
+package mock
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// readJSONFile 读取JSON数组文件，每个元素须为对象，
+// 非对象元素在skipBad为true时跳过并打印警告，否则返回包含文件名和下标的错误
+func readJSONFile(fileName string, skipBad bool) ([]interface{}, error) {
+	b, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	var items []interface{}
+	if err := json.Unmarshal(b, &items); err != nil {
+		return nil, fmt.Errorf("%s: expected a JSON array of objects: %w", fileName, err)
+	}
+
+	records := make([]interface{}, 0, len(items))
+	for i, item := range items {
+		if _, ok := item.(map[string]interface{}); !ok {
+			if !skipBad {
+				return nil, fmt.Errorf("%s[%d]: expected an object, got %s", fileName, i, jsonTypeOf(item))
+			}
+			fmt.Printf("skipping %s[%d]: expected an object, got %s\n", fileName, i, jsonTypeOf(item))
+			continue
+		}
+		records = append(records, item)
+	}
+	return records, nil
+}
+
+// readNDJSON 逐行读取NDJSON文件，空行忽略，每行须为一个JSON对象，
+// 无法解析的行在skipBad为true时跳过并打印警告，否则返回包含文件名和行号的错误
+func readNDJSON(fileName string, skipBad bool) ([]interface{}, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var records []interface{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		var record map[string]interface{}
+		if err := json.Unmarshal(text, &record); err != nil || record == nil {
+			if err == nil {
+				err = fmt.Errorf("expected an object")
+			}
+			if !skipBad {
+				return nil, fmt.Errorf("%s:%d: %w", fileName, line, err)
+			}
+			fmt.Printf("skipping %s:%d: %v\n", fileName, line, err)
+			continue
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", fileName, err)
+	}
+	return records, nil
+}
+
+// jsonTypeOf 返回json.Unmarshal得到的值对应的JSON类型名
+func jsonTypeOf(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	}
+	return "number"
+}
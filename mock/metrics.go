@@ -0,0 +1,108 @@
+package mock
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// httpMetrics 一个服务器实例的请求指标，注册在独立的Registry中，
+// 同一进程中嵌入的多个服务器各自统计，互不影响
+type httpMetrics struct {
+	registry    *prometheus.Registry
+	handler     http.Handler
+	requests    *prometheus.CounterVec
+	latency     *prometheus.HistogramVec
+	uploadBytes prometheus.Counter
+}
+
+func newHTTPMetrics() *httpMetrics {
+	m := &httpMetrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "mock_http_requests_total",
+			Help: "Total HTTP requests by route, method and status.",
+		}, []string{"path", "method", "status"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "mock_http_request_duration_seconds",
+			Help:    "HTTP request latency by route.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"path"}),
+		uploadBytes: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "mock_upload_bytes_total",
+			Help: "Total bytes stored by the file server.",
+		}),
+	}
+	m.registry.MustRegister(m.requests, m.latency, m.uploadBytes)
+	m.handler = promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+	return m
+}
+
+// addUploadBytes 累计上传字节数，未启用指标（m为nil）时忽略
+func (m *httpMetrics) addUploadBytes(n int64) {
+	if m == nil {
+		return
+	}
+	m.uploadBytes.Add(float64(n))
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusRecorder) WriteHeader(code int) {
+	w.status = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Unwrap 使http.ResponseController可以访问底层ResponseWriter（如Flush）
+func (w *statusRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// middleware 记录请求数与耗时，路径标签使用ServeMux匹配到的路由模式以避免标签爆炸
+func (m *httpMetrics) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		start := time.Now()
+		next.ServeHTTP(rec, r)
+		elapsed := time.Since(start).Seconds()
+
+		path := r.Pattern
+		if path == "" {
+			path = "unmatched"
+		}
+		m.requests.WithLabelValues(path, r.Method, strconv.Itoa(rec.status)).Inc()
+		m.latency.WithLabelValues(path).Observe(elapsed)
+	})
+}
+
+// ServeHTTP 以Prometheus文本格式输出本实例的指标
+func (m *httpMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.handler.ServeHTTP(w, r)
+}
+
+// instrument m不为nil时包装mux，并在主端口或独立端口上暴露/metrics
+func instrument(mux *http.ServeMux, m *httpMetrics, port int) http.Handler {
+	if m == nil {
+		return mux
+	}
+	if port == 0 {
+		mux.Handle("/metrics", m)
+	} else {
+		metricsMux := http.NewServeMux()
+		metricsMux.Handle("/metrics", m)
+		go func() {
+			fmt.Printf("Metrics listening at :%d/metrics\n", port)
+			if err := http.ListenAndServe(fmt.Sprintf(":%d", port), metricsMux); err != nil {
+				fmt.Printf("metrics server failed: %v\n", err)
+			}
+		}()
+	}
+	return m.middleware(mux)
+}
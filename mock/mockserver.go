@@ -1,14 +1,18 @@
 package mock
 
 import (
-	"encoding/csv"
+	"context"
 	"encoding/json"
 	"fmt"
-	"github.com/ryanolee/go-chaff"
 	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
+	"syscall"
+	"time"
 )
 
 const schema = `{
@@ -19,31 +23,91 @@ const schema = `{
 	"required": ["id", "name"]
 }`
 
-var data map[string][]interface{}
+// dataSets 按名称保存数据集，查询与重新加载并发访问时通过读写锁保护
+type dataSets struct {
+	mu      sync.RWMutex
+	records map[string][]interface{}
+}
+
+// get 返回指定名称的数据集，返回的切片在替换后不会被修改，可在锁外读取
+func (d *dataSets) get(name string) []interface{} {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.records[name]
+}
+
+// set 替换单个数据集
+func (d *dataSets) set(name string, records []interface{}) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
 
-func loadFile(fileName string) error {
-	file, err := os.Open(fileName)
+	d.records[name] = records
+}
+
+// swap 整体替换全部数据集
+func (d *dataSets) swap(records map[string][]interface{}) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.records = records
+}
+
+var data = &dataSets{records: make(map[string][]interface{})}
+
+// loadFile 按扩展名读取数据文件：.json为对象数组，.ndjson/.jsonl为逐行对象，其余按CSV解析，
+// 数据集以去掉扩展名的文件名命名
+func (o *MockServerOptions) loadFile(fileName string, into map[string][]interface{}) error {
+	var loadJSON func(string, bool) ([]interface{}, error)
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".json":
+		loadJSON = readJSONFile
+	case ".ndjson", ".jsonl":
+		loadJSON = readNDJSON
+	default:
+		return o.loadCSV(fileName, into)
+	}
+
+	records, err := loadJSON(fileName, o.BadRows == "skip")
 	if err != nil {
 		return err
 	}
-	defer file.Close()
+	into[fileNameWithoutExtension(fileName)] = records
+	fmt.Printf("loaded %d records from %s\n", len(records), fileName)
+	return nil
+}
 
-	reader := csv.NewReader(file)
-	records, err := reader.ReadAll()
+func (o *MockServerOptions) loadCSV(fileName string, into map[string][]interface{}) error {
+	records, err := readCSV(fileName, o.BadRows == "skip")
 	if err != nil {
 		return err
 	}
-	header := records[0]
-	rs := make([]map[string]string, len(records)-1)
+	columns := parseCSVHeader(records[0])
+	for j := range columns {
+		switch {
+		case o.NoInfer:
+			// 保持原有行为：所有值均为字符串，表头注解仍然生效
+			if columns[j].typ == "" {
+				columns[j].typ = "string"
+			}
+		case columns[j].typ == "":
+			values := make([]string, 0, len(records)-1)
+			for i := 1; i < len(records); i++ {
+				values = append(values, records[i][j])
+			}
+			columns[j].typ = inferColumnType(values)
+		}
+	}
+	rs := make([]map[string]interface{}, len(records)-1)
 	for i := 1; i < len(records); i++ {
-		rs[i-1] = make(map[string]string)
-		for j := 0; j < len(header); j++ {
-			rs[i-1][header[j]] = records[i][j]
+		rs[i-1] = make(map[string]interface{})
+		for j, col := range columns {
+			rs[i-1][col.name] = convertCSVValue(records[i][j], col.typ)
 		}
 	}
 	fileNameWithoutExt := fileNameWithoutExtension(fileName)
-	data[fileNameWithoutExt] = make([]interface{}, len(rs))
-	d := data[fileNameWithoutExt]
+	into[fileNameWithoutExt] = make([]interface{}, len(rs))
+	d := into[fileNameWithoutExt]
 	for i := 0; i < len(rs); i++ {
 		d[i] = rs[i]
 	}
@@ -51,44 +115,111 @@ func loadFile(fileName string) error {
 	return nil
 }
 
-func loadRandomData(size int) error {
-	data["default"] = make([]interface{}, size)
-	d := data["default"]
-	for i := 0; i < size; i++ {
-		generator, err := chaff.ParseSchemaStringWithDefaults(schema)
-		if err != nil {
-			return err
-		}
-
-		result := generator.GenerateWithDefaults()
-
-		d = append(d, result)
+// loadRandomData 按模式生成size条随机记录作为default数据集
+func loadRandomData(schemaJSON string, size int, into map[string][]interface{}) error {
+	records, err := generateRecords(schemaJSON, size)
+	if err != nil {
+		return err
 	}
+	into["default"] = records
 	return nil
 }
 
+// generateData 重新加载全部数据集，成功后整体替换data，失败时保留原数据
 func (o *MockServerOptions) generateData() error {
-	data = make(map[string][]interface{})
+	newData := make(map[string][]interface{})
+
+	schemaJSON, err := readSchema(o.Schema)
+	if err != nil {
+		return err
+	}
 
 	if o.CsvFiles != "" {
 		files := strings.Split(o.CsvFiles, ";")
 		for _, file := range files {
-			err := loadFile(file)
+			err := o.loadFile(file, newData)
 			if err != nil {
 				return err
 			}
 		}
 	} else {
-		err := loadRandomData(o.Size)
+		err := loadRandomData(schemaJSON, o.Size, newData)
 		if err != nil {
 			return err
 		}
 	}
+
+	if o.Validate {
+		if err := o.validateData(schemaJSON, newData); err != nil {
+			return err
+		}
+	}
+
+	data.swap(newData)
 	return nil
 }
 
+// validateData 按模式校验各数据集，不符合的记录按--bad-rows丢弃或返回错误
+func (o *MockServerOptions) validateData(schemaJSON string, sets map[string][]interface{}) error {
+	s, err := parseJSONSchema(schemaJSON)
+	if err != nil {
+		return err
+	}
+	for name, records := range sets {
+		valid, err := validateRecords(s, name, records, o.BadRows == "skip")
+		if err != nil {
+			return err
+		}
+		sets[name] = valid
+	}
+	return nil
+}
+
+// reloadOnSignal 收到SIGHUP时重新加载数据集，启用--watch时同时重新开始监控；ctx结束时返回
+func (o *MockServerOptions) reloadOnSignal(ctx context.Context) {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGHUP)
+	defer signal.Stop(sigCh)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sigCh:
+		}
+		if err := o.generateData(); err != nil {
+			fmt.Printf("reload failed, keeping previous data: %v\n", err)
+			continue
+		}
+		fmt.Println("data reloaded")
+		if o.Watch {
+			if err := o.startWatch(ctx); err != nil {
+				fmt.Printf("restart watch failed: %v\n", err)
+			}
+		}
+	}
+}
+
+func (o *MockServerOptions) reloadHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, `{"Status": {"Code": "1", "Message": "POST method only"}}`, http.StatusOK)
+		return
+	}
+
+	resp := Response{Status: Status{Code: "0", Message: "OK"}}
+	if err := o.generateData(); err != nil {
+		resp.Status = Status{Code: "4", Message: fmt.Sprintf("reload failed: %v", err)}
+	}
+	res, _ := json.Marshal(resp)
+	fmt.Fprintf(w, "%s", res)
+}
+
 type Result struct {
 	Data interface{} `json:"Data"`
+	// 分页信息，Total为数据集记录总数
+	Total      int `json:"Total"`
+	PageNo     int `json:"PageNo"`
+	PageSize   int `json:"PageSize"`
+	TotalPages int `json:"TotalPages"`
 }
 
 type MockResponse struct {
@@ -101,35 +232,112 @@ func (o *MockServerOptions) Run() error {
 		return fmt.Errorf("size to large, max 10000")
 	}
 
-	err := o.generateData()
+	if o.ErrorRate < 0 || o.ErrorRate > 1 {
+		return fmt.Errorf("error rate must be between 0 and 1, got %v", o.ErrorRate)
+	}
+	latency, err := parseLatency(o.Latency)
 	if err != nil {
 		return err
 	}
+	o.latency = latency
 
-	mux := http.NewServeMux()
-	mux.HandleFunc("/api/mock/query/{rs}", o.queryHandler)
+	// 启动时校验模式，避免生成或校验时才发现问题
+	schemaJSON, err := readSchema(o.Schema)
+	if err != nil {
+		return err
+	}
+	if err := checkSchema(schemaJSON); err != nil {
+		if o.Schema == "" {
+			return fmt.Errorf("invalid built-in schema: %w", err)
+		}
+		return fmt.Errorf("invalid schema %s: %w", o.Schema, err)
+	}
 
+	err = o.generateData()
+	if err != nil {
+		return err
+	}
+
+	routes := newRouteTable()
+	routes.handle("/api/mock/query/{rs}", o.queryHandler, http.MethodGet, http.MethodPost)
+	routes.handle("/api/mock/get/{rs}/{id}", o.getHandler, http.MethodGet)
+	routes.handle("/api/mock/reload", o.reloadHandler, http.MethodPost)
+
+	// Ctrl-C/SIGTERM后关闭服务器，并停止重新加载和文件监控
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if o.Watch {
+		if err := o.startWatch(ctx); err != nil {
+			return err
+		}
+	}
+	go o.reloadOnSignal(ctx)
+
+	if o.Metrics {
+		o.metrics = newHTTPMetrics()
+	}
+	h := instrument(routes.mux, o.metrics, o.MetricsPort)
+	if o.CorsOrigin != "" {
+		h = corsMiddleware(o.CorsOrigin, routes, h)
+	}
+
+	server := &http.Server{Addr: fmt.Sprintf(":%d", o.Port), Handler: h}
+	context.AfterFunc(ctx, func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	})
 	fmt.Printf("Server listening at :%d\n", o.Port)
-	if err := http.ListenAndServe(fmt.Sprintf(":%d", o.Port), mux); err != nil {
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 		return fmt.Errorf("server listen failed: %v", err)
 	}
 	return nil
 }
 
 type queryRequest struct {
-	PageNo   int `json:"pageNo"`
-	PageSize int `json:"pageSize"`
+	PageNo   int        `json:"pageNo"`
+	PageSize int        `json:"pageSize"`
+	Sort     []sortSpec `json:"sort"`
+	// 字段名 -> 过滤条件，在排序和分页之前应用
+	Filter map[string]filterCond `json:"filter"`
 }
 
+// parseQueryString 从GET请求的查询串读取分页参数（pageNo、pageSize）
+func parseQueryString(r *http.Request) (queryRequest, error) {
+	var req queryRequest
+	q := r.URL.Query()
+	for name, dst := range map[string]*int{"pageNo": &req.PageNo, "pageSize": &req.PageSize} {
+		if v := q.Get(name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return req, fmt.Errorf("%s: %w", name, err)
+			}
+			*dst = n
+		}
+	}
+	return req, nil
+}
+
+// queryHandler 分页查询数据集，POST时参数在JSON请求体中，GET时分页参数在查询串中
 func (o *MockServerOptions) queryHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, `{"Status": {"Code": "1", "Message": "POST method only"}}`, http.StatusOK)
+	if r.Method != http.MethodPost && r.Method != http.MethodGet {
+		http.Error(w, `{"Status": {"Code": "1", "Message": "GET or POST method only"}}`, http.StatusOK)
+		return
+	}
+
+	// 按配置注入延迟和错误
+	if o.misbehave(w, r) {
 		return
 	}
 
 	var req queryRequest
-	err := json.NewDecoder(r.Body).Decode(&req)
-	if err != nil {
+	if r.Method == http.MethodGet {
+		var err error
+		if req, err = parseQueryString(r); err != nil {
+			http.Error(w, `{"Status": {"Code": "2", "Message": "query parameter parsing error"}}`, http.StatusOK)
+			return
+		}
+	} else if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, `{"Status": {"Code": "2", "Message": "JSON parsing error"}}`, http.StatusOK)
 		return
 	}
@@ -141,18 +349,32 @@ func (o *MockServerOptions) queryHandler(w http.ResponseWriter, r *http.Request)
 	if len(rsName) == 0 {
 		rsName = "default"
 	}
-	d := data[rsName]
+	d := sortRecords(filterRecords(data.get(rsName), req.Filter), req.Sort)
+
+	// pageSize未指定时返回全部记录
+	if pageSize <= 0 {
+		pageSize = max(len(d), 1)
+	}
 
 	maxPageNo := (len(d) + pageSize - 1) / pageSize
-	fmt.Println("len(d): ", len(d))
-	fmt.Printf("pageNo: %d, pageSize: %d, maxPageNo: %d\n", pageNo, pageSize, maxPageNo)
-	var result interface{}
-	if pageNo > maxPageNo {
-		result = []interface{}{}
-	} else {
+	result := []interface{}{}
+	if pageNo <= maxPageNo {
 		result = d[(pageNo-1)*pageSize : min(len(d), pageNo*pageSize)]
 	}
 
+	page := Result{
+		Data:       result,
+		Total:      len(d),
+		PageNo:     pageNo,
+		PageSize:   pageSize,
+		TotalPages: maxPageNo,
+	}
+
+	if r.URL.Query().Get("stream") == "true" {
+		streamResult(w, page)
+		return
+	}
+
 	resp := MockResponse{
 		Response: Response{
 			Status: Status{
@@ -160,9 +382,7 @@ func (o *MockServerOptions) queryHandler(w http.ResponseWriter, r *http.Request)
 				Message: "OK",
 			},
 		},
-		Result: Result{
-			Data: result,
-		},
+		Result: page,
 	}
 	res, err := json.Marshal(resp)
 	if err != nil {
@@ -174,6 +394,76 @@ func (o *MockServerOptions) queryHandler(w http.ResponseWriter, r *http.Request)
 	return
 }
 
+// findRecord 查找id字段与给定值相等的记录，数值与字符串形式的id均可匹配
+func findRecord(records []interface{}, id string) (interface{}, bool) {
+	for _, record := range records {
+		v := recordField(record, "id")
+		if v != nil && fmt.Sprint(v) == id {
+			return record, true
+		}
+	}
+	return nil, false
+}
+
+// getHandler 按id返回单条记录，结果以只含一条记录的一页返回
+func (o *MockServerOptions) getHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, `{"Status": {"Code": "1", "Message": "GET method only"}}`, http.StatusOK)
+		return
+	}
+
+	// 按配置注入延迟和错误
+	if o.misbehave(w, r) {
+		return
+	}
+
+	record, ok := findRecord(data.get(r.PathValue("rs")), r.PathValue("id"))
+	if !ok {
+		http.Error(w, `{"Status": {"Code": "5", "Message": "record not found"}}`, http.StatusOK)
+		return
+	}
+
+	resp := MockResponse{
+		Response: Response{Status: Status{Code: "0", Message: "OK"}},
+		Result:   Result{Data: record, Total: 1, PageNo: 1, PageSize: 1, TotalPages: 1},
+	}
+	res, err := json.Marshal(resp)
+	if err != nil {
+		http.Error(w, `{"Status": {"Code": "3", "Message": "JSON generating error"}}`, http.StatusOK)
+		return
+	}
+	fmt.Fprintf(w, "%s", res)
+}
+
+// streamFlushEvery 流式响应每写出多少条记录刷新一次
+const streamFlushEvery = 100
+
+// streamResult 逐条编码记录并定期刷新，避免在内存中构建完整响应
+func streamResult(w http.ResponseWriter, page Result) {
+	w.Header().Set("Content-Type", "application/json")
+	rc := http.NewResponseController(w)
+	enc := json.NewEncoder(w)
+	records, _ := page.Data.([]interface{})
+
+	fmt.Fprintf(w, `{"Status":{"Code":"0","Message":"OK"},"Result":{"Total":%d,"PageNo":%d,"PageSize":%d,"TotalPages":%d,"Data":[`,
+		page.Total, page.PageNo, page.PageSize, page.TotalPages)
+	for i, record := range records {
+		if i > 0 {
+			fmt.Fprint(w, ",")
+		}
+		if err := enc.Encode(record); err != nil {
+			// 响应头已发送，只能中断输出
+			fmt.Printf("stream encoding failed: %v\n", err)
+			return
+		}
+		if (i+1)%streamFlushEvery == 0 {
+			rc.Flush()
+		}
+	}
+	fmt.Fprint(w, "]}}")
+	rc.Flush()
+}
+
 func fileNameWithoutExtension(fileName string) string {
 	return strings.TrimSuffix(filepath.Base(fileName), filepath.Ext(fileName))
 }
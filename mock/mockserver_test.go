@@ -0,0 +1,27 @@
+package mock
+
+import "testing"
+
+func TestRandomDataIsServed(t *testing.T) {
+	o := &MockServerOptions{Size: 20}
+	if err := o.generateData(); err != nil {
+		t.Fatalf("generateData: %v", err)
+	}
+	records := data.get("default")
+	if len(records) != o.Size {
+		t.Fatalf("got %d records, want %d", len(records), o.Size)
+	}
+
+	s, err := parseJSONSchema(schema)
+	if err != nil {
+		t.Fatalf("parseJSONSchema: %v", err)
+	}
+	for i, r := range records {
+		if r == nil {
+			t.Fatalf("record %d is nil", i)
+		}
+		if err := validateRecord(s, r); err != nil {
+			t.Errorf("record %d does not match the schema: %v", i, err)
+		}
+	}
+}
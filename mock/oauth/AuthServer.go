@@ -5,12 +5,15 @@ import (
 	"embed"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
 	"io/fs"
 	"log"
 	"net/http"
 	"net/url"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -23,10 +26,17 @@ var embeddedFiles embed.FS
 
 // 客户端信息
 type Client struct {
-	ID           string
-	Name         string
-	Secret       string
-	RedirectURIs []string
+	ID           string   `json:"id" yaml:"id"`
+	Name         string   `json:"name" yaml:"name"`
+	Secret       string   `json:"secret" yaml:"secret"`
+	RedirectURIs []string `json:"redirect_uris" yaml:"redirect_uris"`
+	// redirect_uri匹配方式：exact（默认）或prefix
+	RedirectMatch string `json:"redirect_match" yaml:"redirect_match"`
+	// 登出后允许重定向的地址
+	PostLogoutRedirectURIs []string `json:"post_logout_redirect_uris" yaml:"post_logout_redirect_uris"`
+	// 所有授权类型可申请的scope，为空时不限制；超出范围返回invalid_scope，
+	// client_credentials未指定scope时授予全部允许的scope
+	AllowedScopes []string `json:"allowed_scopes" yaml:"allowed_scopes"`
 }
 
 // 授权码
@@ -37,6 +47,11 @@ type AuthorizationCode struct {
 	ExpiresAt   time.Time
 	Scope       string
 	UserID      string
+	// PKCE (RFC 7636)，CodeChallenge为空表示未使用PKCE
+	CodeChallenge       string
+	CodeChallengeMethod string
+	// 授权请求中的nonce，原样写入id_token
+	Nonce string
 }
 
 // 访问令牌
@@ -44,11 +59,35 @@ type AccessToken struct {
 	Token     string
 	Type      string
 	ExpiresIn int64
+	ExpiresAt time.Time
 	Scope     string
 	UserID    string
 	ClientID  string
+	// 令牌端点同时签发的刷新令牌和id_token，为空时响应中不返回
+	RefreshToken string
+	IDToken      string
+}
+
+// expired 令牌是否已超过有效期
+func (t *AccessToken) expired() bool {
+	return !t.ExpiresAt.IsZero() && time.Now().After(t.ExpiresAt)
+}
+
+// accessTokenTTL 访问令牌有效期
+const accessTokenTTL = time.Hour
+
+// 刷新令牌，使用后即作废并签发新的刷新令牌
+type RefreshToken struct {
+	Token     string
+	ClientID  string
+	UserID    string
+	Scope     string
+	ExpiresAt time.Time
 }
 
+// refreshTokenTTL 刷新令牌有效期
+const refreshTokenTTL = 24 * time.Hour
+
 // JWT 声明结构
 type JwtCustomClaims struct {
 	UserID   string `json:"user_id"`
@@ -57,11 +96,19 @@ type JwtCustomClaims struct {
 	jwt.RegisteredClaims
 }
 
-// 用户信息
+// 用户信息，除ID外的声明按scope在userinfo中返回
 type User struct {
-	ID       string
-	Username string
-	Password string
+	ID            string `json:"id" yaml:"id"`
+	Username      string `json:"username" yaml:"username"`
+	Password      string `json:"password" yaml:"password"`
+	Name          string `json:"name" yaml:"name"`
+	GivenName     string `json:"given_name" yaml:"given_name"`
+	FamilyName    string `json:"family_name" yaml:"family_name"`
+	Email         string `json:"email" yaml:"email"`
+	EmailVerified bool   `json:"email_verified" yaml:"email_verified"`
+	PhoneNumber   string `json:"phone_number" yaml:"phone_number"`
+	// 额外声明，在userinfo中总是返回，不覆盖标准声明
+	Claims map[string]interface{} `json:"claims" yaml:"claims"`
 }
 
 // 授权请求会话
@@ -74,62 +121,127 @@ type AuthRequest struct {
 	Scope        string
 	UserID       string
 	ExpiresAt    time.Time
+	ResponseMode string // query（默认）或form_post
+	// PKCE (RFC 7636)
+	CodeChallenge       string
+	CodeChallengeMethod string
+	Nonce               string
+}
+
+// defaultJwtSecret 未配置JwtSecret时HS256使用的共享密钥
+const defaultJwtSecret = "your-256-bit-secret"
+
+// Config 认证服务器配置
+type Config struct {
+	AdminSecret string // 管理接口密钥，为空时禁用管理接口
+	Issuer      string // 服务器外部地址，用作JWT的iss及发现文档中各端点的前缀，默认 http://localhost
+	SigningAlg  string // JWT签名算法，RS256（默认，启动时生成密钥）或HS256（使用共享密钥）
+	JwtSecret   string // HS256使用的共享密钥，默认 your-256-bit-secret
+	// 启动时加载的客户端与用户，均为空时使用内置的示例数据
+	Clients []*Client
+	Users   []*User
 }
 
 // AuthServer 结构体，包含所有服务器状态
 type AuthServer struct {
-	clients      map[string]*Client
-	users        map[string]*User
-	authCodes    map[string]*AuthorizationCode
-	accessTokens map[string]*AccessToken
-	authRequests map[string]*AuthRequest
-	sessions     map[string]string
-	templates    *template.Template
-	staticFS     http.FileSystem
-	jwtSecret    []byte // 用于签名JWT的密钥
+	// mu 保护下面的各个状态map，HTTP处理器并发执行，访问前需加锁
+	mu             sync.RWMutex
+	clients        map[string]*Client
+	users          map[string]*User
+	authCodes      map[string]*AuthorizationCode
+	accessTokens   map[string]*AccessToken
+	refreshTokens  map[string]*RefreshToken
+	authRequests   map[string]*AuthRequest
+	deviceRequests map[string]*DeviceRequest // 设备码 -> 设备授权请求
+	sessions       map[string]string
+	consents       map[string]string // 用户ID+客户端ID -> 已同意的scope
+	templates      *template.Template
+	staticFS       http.FileSystem
+	signingKey     *signingKey // 用于签名JWT的密钥
+	issuer         string
+	adminSecret    string
+	faults         *faultInjector
 }
 
 // NewAuthServer 创建并初始化一个新的认证服务器实例
-func NewAuthServer() *AuthServer {
+func NewAuthServer(cfg Config) (*AuthServer, error) {
 	server := &AuthServer{
-		clients:      make(map[string]*Client),
-		users:        make(map[string]*User),
-		authCodes:    make(map[string]*AuthorizationCode),
-		accessTokens: make(map[string]*AccessToken),
-		authRequests: make(map[string]*AuthRequest),
-		sessions:     make(map[string]string),
-		jwtSecret:    []byte("your-256-bit-secret"), // 请使用更安全的密钥
+		clients:        make(map[string]*Client),
+		users:          make(map[string]*User),
+		authCodes:      make(map[string]*AuthorizationCode),
+		accessTokens:   make(map[string]*AccessToken),
+		refreshTokens:  make(map[string]*RefreshToken),
+		authRequests:   make(map[string]*AuthRequest),
+		deviceRequests: make(map[string]*DeviceRequest),
+		sessions:       make(map[string]string),
+		consents:       make(map[string]string),
+		adminSecret:    cfg.AdminSecret,
+		issuer:         strings.TrimSuffix(cfg.Issuer, "/"),
+		faults:         newFaultInjector(),
 	}
 
-	// 初始化示例数据
-	server.clients["client1"] = &Client{
-		ID:           "client1",
-		Name:         "示例应用",
-		Secret:       "secret1",
-		RedirectURIs: []string{"http://localhost:8080/login/oauth2/code/custom-auth-server"},
+	if server.issuer == "" {
+		server.issuer = "http://localhost"
 	}
 
-	server.users["user1"] = &User{
-		ID:       "user1",
-		Username: "alice",
-		Password: "password123",
+	// HS256时使用的共享密钥，未配置时使用默认值，请使用更安全的密钥
+	secret := cfg.JwtSecret
+	if secret == "" {
+		secret = defaultJwtSecret
+	}
+	key, err := newSigningKey(cfg.SigningAlg, []byte(secret))
+	if err != nil {
+		return nil, err
+	}
+	server.signingKey = key
+
+	if len(cfg.Clients) == 0 && len(cfg.Users) == 0 {
+		server.addDemoData()
+	}
+	for _, c := range cfg.Clients {
+		server.clients[c.ID] = c
+	}
+	for _, u := range cfg.Users {
+		server.users[u.ID] = u
 	}
 
 	// 解析模板
 	templates, err := parseTemplates()
 	if err != nil {
-		log.Fatal("Failed to parse templates:", err)
+		return nil, fmt.Errorf("failed to parse templates: %w", err)
 	}
 	server.templates = templates
 
 	// 创建静态文件系统
 	staticFS, err := fs.Sub(embeddedFiles, "static")
 	if err != nil {
-		log.Fatal("Failed to create static filesystem:", err)
+		return nil, fmt.Errorf("failed to create static filesystem: %w", err)
 	}
 	server.staticFS = http.FS(staticFS)
 
-	return server
+	return server, nil
+}
+
+// addDemoData 初始化示例客户端与用户
+func (s *AuthServer) addDemoData() {
+	s.clients["client1"] = &Client{
+		ID:           "client1",
+		Name:         "示例应用",
+		Secret:       "secret1",
+		RedirectURIs: []string{"http://localhost:8080/login/oauth2/code/custom-auth-server"},
+	}
+
+	s.users["user1"] = &User{
+		ID:            "user1",
+		Username:      "alice",
+		Password:      "password123",
+		Name:          "Alice Smith",
+		GivenName:     "Alice",
+		FamilyName:    "Smith",
+		Email:         "alice@example.com",
+		EmailVerified: true,
+		PhoneNumber:   "+1 555 0100",
+	}
 }
 
 // parseTemplates 从嵌入的文件系统中解析模板
@@ -170,10 +282,19 @@ func (s *AuthServer) SetupRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("/clients", s.clientsHandler)
 	mux.HandleFunc("/login", s.loginHandler)
 	mux.HandleFunc("/auth", s.authHandler)
-	mux.HandleFunc("/authorize", s.authorizeHandler)
-	mux.HandleFunc("/token", s.tokenHandler)
-	mux.HandleFunc("/userinfo", s.userInfoHandler)
-	mux.HandleFunc("/verify", s.verifyTokenHandler)
+	mux.HandleFunc("/authorize", s.withFaults("/authorize", s.authorizeHandler))
+	mux.HandleFunc("/token", s.withFaults("/token", s.tokenHandler))
+	mux.HandleFunc("/userinfo", s.withFaults("/userinfo", s.userInfoHandler))
+	mux.HandleFunc("/verify", s.withFaults("/verify", s.verifyTokenHandler))
+	mux.HandleFunc("/revoke", s.withFaults("/revoke", s.revokeHandler))
+	mux.HandleFunc("/introspect", s.withFaults("/introspect", s.introspectHandler))
+	mux.HandleFunc("/device_authorization", s.withFaults("/device_authorization", s.deviceAuthorizationHandler))
+	mux.HandleFunc("/device", s.deviceHandler)
+	mux.HandleFunc("/logout", s.logoutHandler)
+	mux.HandleFunc("/.well-known/openid-configuration", s.discoveryHandler)
+	mux.HandleFunc("/jwks.json", s.jwksHandler)
+	mux.HandleFunc("/admin/token", s.adminTokenHandler)
+	mux.HandleFunc("/admin/errors", s.adminErrorsHandler)
 
 	// 静态文件服务
 	mux.Handle("/static/", http.StripPrefix("/static/", http.FileServer(s.staticFS)))
@@ -181,6 +302,9 @@ func (s *AuthServer) SetupRoutes(mux *http.ServeMux) {
 
 // 首页处理器
 func (s *AuthServer) homeHandler(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	data := map[string]interface{}{
 		"Clients": s.clients,
 	}
@@ -193,6 +317,8 @@ func (s *AuthServer) homeHandler(w http.ResponseWriter, r *http.Request) {
 func (s *AuthServer) clientsHandler(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case "GET":
+		s.mu.RLock()
+		defer s.mu.RUnlock()
 		data := map[string]interface{}{
 			"Clients": s.clients,
 		}
@@ -208,11 +334,15 @@ func (s *AuthServer) clientsHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *AuthServer) addClients(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	type Input struct {
-		ClientID     string `json:"clientId"`
-		ClientName   string `json:"clientName"`
-		ClientSecret string `json:"clientSecret"`
-		RedirectURI  string `json:"redirectUri"`
+		ClientID      string   `json:"clientId"`
+		ClientName    string   `json:"clientName"`
+		ClientSecret  string   `json:"clientSecret"`
+		RedirectURI   string   `json:"redirectUri"`
+		AllowedScopes []string `json:"allowedScopes"`
 	}
 
 	var input Input
@@ -228,16 +358,20 @@ func (s *AuthServer) addClients(w http.ResponseWriter, r *http.Request) {
 	}
 
 	client := &Client{
-		ID:           input.ClientID,
-		Name:         input.ClientName,
-		Secret:       input.ClientSecret,
-		RedirectURIs: []string{input.RedirectURI},
+		ID:            input.ClientID,
+		Name:          input.ClientName,
+		Secret:        input.ClientSecret,
+		RedirectURIs:  []string{input.RedirectURI},
+		AllowedScopes: input.AllowedScopes,
 	}
 	s.clients[client.ID] = client
 }
 
 // 登录页面处理器
 func (s *AuthServer) loginHandler(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	if r.Method == "GET" {
 		// 显示登录页面
 		authRequestID := r.URL.Query().Get("request_id")
@@ -247,6 +381,8 @@ func (s *AuthServer) loginHandler(w http.ResponseWriter, r *http.Request) {
 			"AuthRequestID": authRequestID,
 			"ClientID":      clientID,
 			"Client":        s.clients[clientID],
+			"Device":        r.URL.Query().Get("device") != "",
+			"UserCode":      r.URL.Query().Get("user_code"),
 		}
 		err := s.templates.ExecuteTemplate(w, "login.html", data)
 		if err != nil {
@@ -299,12 +435,21 @@ func (s *AuthServer) loginHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// 从设备验证页面跳转来的登录，回到设备验证页面
+	if r.FormValue("device") != "" {
+		http.Redirect(w, r, "/device?user_code="+url.QueryEscape(r.FormValue("user_code")), http.StatusFound)
+		return
+	}
+
 	// 如果没有特定授权请求，重定向到首页
 	http.Redirect(w, r, "/", http.StatusFound)
 }
 
 // 授权页面处理器
 func (s *AuthServer) authHandler(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	// 检查会话
 	sessionID, err := r.Cookie("oauth_session")
 	if err != nil {
@@ -331,6 +476,7 @@ func (s *AuthServer) authHandler(w http.ResponseWriter, r *http.Request) {
 			"AuthRequest": authRequest,
 			"Client":      s.clients[authRequest.ClientID],
 			"User":        s.users[userID],
+			"Scopes":      describeScopes(authRequest.Scope),
 		}
 		err := s.templates.ExecuteTemplate(w, "auth.html", data)
 		if err != nil {
@@ -345,18 +491,18 @@ func (s *AuthServer) authHandler(w http.ResponseWriter, r *http.Request) {
 
 	if decision != "allow" {
 		// 用户拒绝授权
-		redirectURL, _ := url.Parse(authRequest.RedirectURI)
-		params := redirectURL.Query()
-		params.Add("error", "access_denied")
-		if authRequest.State != "" {
-			params.Add("state", authRequest.State)
-		}
-		redirectURL.RawQuery = params.Encode()
-		http.Redirect(w, r, redirectURL.String(), http.StatusFound)
+		s.redirectError(w, r, authRequest, "access_denied")
 		return
 	}
 
-	// 用户同意授权，生成授权码
+	// 记住用户同意，供后续 prompt=none 静默授权使用
+	s.consents[consentKey(authRequest.UserID, authRequest.ClientID)] = authRequest.Scope
+
+	s.issueCode(w, r, authRequest)
+}
+
+// issueCode 为已登录且已同意的授权请求生成授权码并重定向回客户端
+func (s *AuthServer) issueCode(w http.ResponseWriter, r *http.Request, authRequest *AuthRequest) {
 	code, err := generateRandomString(32)
 	if err != nil {
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
@@ -371,27 +517,25 @@ func (s *AuthServer) authHandler(w http.ResponseWriter, r *http.Request) {
 		ExpiresAt:   time.Now().Add(10 * time.Minute),
 		Scope:       authRequest.Scope,
 		UserID:      authRequest.UserID,
-	}
-	s.authCodes[code] = authCode
 
-	// 构建重定向URL
-	redirectURL, _ := url.Parse(authRequest.RedirectURI)
-	params := redirectURL.Query()
-	params.Add("code", code)
-	if authRequest.State != "" {
-		params.Add("state", authRequest.State)
+		CodeChallenge:       authRequest.CodeChallenge,
+		CodeChallengeMethod: authRequest.CodeChallengeMethod,
+		Nonce:               authRequest.Nonce,
 	}
-	redirectURL.RawQuery = params.Encode()
+	s.authCodes[code] = authCode
 
 	// 清理授权请求
-	delete(s.authRequests, authRequestID)
+	delete(s.authRequests, authRequest.ID)
 
-	// 重定向到客户端
-	http.Redirect(w, r, redirectURL.String(), http.StatusFound)
+	// 返回客户端
+	s.sendAuthResponse(w, r, authRequest, url.Values{"code": {code}})
 }
 
 // 授权端点处理器
 func (s *AuthServer) authorizeHandler(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	// 解析查询参数
 	query := r.URL.Query()
 	clientID := query.Get("client_id")
@@ -399,9 +543,14 @@ func (s *AuthServer) authorizeHandler(w http.ResponseWriter, r *http.Request) {
 	responseType := query.Get("response_type")
 	state := query.Get("state")
 	scope := query.Get("scope")
-
-	// 验证必要参数
-	if clientID == "" || redirectURI == "" || responseType != "code" {
+	prompt := query.Get("prompt")
+	codeChallenge := query.Get("code_challenge")
+	codeChallengeMethod := query.Get("code_challenge_method")
+	nonce := query.Get("nonce")
+	responseMode := query.Get("response_mode")
+
+	// 验证必要参数，redirect_uri校验通过前的错误直接显示错误页面
+	if clientID == "" || redirectURI == "" {
 		http.Error(w, "Invalid request parameters", http.StatusBadRequest)
 		return
 	}
@@ -414,16 +563,33 @@ func (s *AuthServer) authorizeHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 验证重定向URI是否已注册
-	validRedirectURI := false
-	for _, uri := range client.RedirectURIs {
-		if uri == redirectURI {
-			validRedirectURI = true
-			break
-		}
+	if !client.allowsRedirectURI(redirectURI) {
+		http.Error(w, "Invalid redirect URI", http.StatusBadRequest)
+		return
 	}
 
-	if !validRedirectURI {
-		http.Error(w, "Invalid redirect URI", http.StatusBadRequest)
+	// 之后的错误以错误码重定向回客户端
+	if responseType != "code" {
+		s.redirectError(w, r, &AuthRequest{RedirectURI: redirectURI, State: state, ResponseMode: responseMode}, "unsupported_response_type")
+		return
+	}
+	if responseMode != "" && responseMode != responseModeQuery && responseMode != responseModeFormPost {
+		s.redirectError(w, r, &AuthRequest{RedirectURI: redirectURI, State: state}, "invalid_request")
+		return
+	}
+
+	// PKCE参数，未指定方法时默认为plain
+	if codeChallenge != "" && codeChallengeMethod == "" {
+		codeChallengeMethod = "plain"
+	}
+	if (codeChallenge == "" && codeChallengeMethod != "") || (codeChallenge != "" && !validChallengeMethod(codeChallengeMethod)) {
+		s.redirectError(w, r, &AuthRequest{RedirectURI: redirectURI, State: state, ResponseMode: responseMode}, "invalid_request")
+		return
+	}
+
+	// 请求的scope必须都在客户端允许范围内
+	if !client.allowsAllScopes(scope) {
+		s.redirectError(w, r, &AuthRequest{RedirectURI: redirectURI, State: state, ResponseMode: responseMode}, "invalid_scope")
 		return
 	}
 
@@ -437,6 +603,16 @@ func (s *AuthServer) authorizeHandler(w http.ResponseWriter, r *http.Request) {
 		State:        state,
 		Scope:        scope,
 		ExpiresAt:    time.Now().Add(10 * time.Minute),
+		ResponseMode: responseMode,
+
+		CodeChallenge:       codeChallenge,
+		CodeChallengeMethod: codeChallengeMethod,
+		Nonce:               nonce,
+	}
+
+	if prompt == "none" {
+		s.silentAuthorize(w, r, s.authRequests[authRequestID])
+		return
 	}
 
 	// 检查用户是否已登录
@@ -459,8 +635,58 @@ func (s *AuthServer) authorizeHandler(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, fmt.Sprintf("/auth?request_id=%s", authRequestID), http.StatusFound)
 }
 
+// silentAuthorize 处理 prompt=none：不显示任何交互页面，会话有效且已同意时直接签发授权码，
+// 否则以 login_required / consent_required 错误重定向回客户端
+func (s *AuthServer) silentAuthorize(w http.ResponseWriter, r *http.Request, authRequest *AuthRequest) {
+	defer delete(s.authRequests, authRequest.ID)
+
+	userID := ""
+	if sessionID, err := r.Cookie("oauth_session"); err == nil {
+		userID = s.sessions[sessionID.Value]
+	}
+	if userID == "" {
+		s.redirectError(w, r, authRequest, "login_required")
+		return
+	}
+
+	granted, consented := s.consents[consentKey(userID, authRequest.ClientID)]
+	if !consented || !scopeCovers(granted, authRequest.Scope) {
+		s.redirectError(w, r, authRequest, "consent_required")
+		return
+	}
+
+	authRequest.UserID = userID
+	s.issueCode(w, r, authRequest)
+}
+
+// redirectError 以OAuth错误码重定向回客户端的redirect_uri
+func (s *AuthServer) redirectError(w http.ResponseWriter, r *http.Request, authRequest *AuthRequest, errCode string) {
+	s.sendAuthResponse(w, r, authRequest, url.Values{"error": {errCode}})
+}
+
+func consentKey(userID, clientID string) string {
+	return userID + "\x00" + clientID
+}
+
+// scopeCovers 判断已授权的scope是否包含请求的全部scope
+func scopeCovers(granted, requested string) bool {
+	have := make(map[string]bool)
+	for _, sc := range strings.Fields(granted) {
+		have[sc] = true
+	}
+	for _, sc := range strings.Fields(requested) {
+		if !have[sc] {
+			return false
+		}
+	}
+	return true
+}
+
 // 令牌端点处理器
 func (s *AuthServer) tokenHandler(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	// 只接受POST请求
 	if r.Method != "POST" {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -481,7 +707,15 @@ func (s *AuthServer) tokenHandler(w http.ResponseWriter, r *http.Request) {
 	clientSecret := r.FormValue("client_secret")
 
 	// 验证授权类型
-	if grantType != "authorization_code" {
+	switch grantType {
+	case "authorization_code", "refresh_token":
+	case "client_credentials":
+		s.clientCredentialsGrant(w, r, clientID, clientSecret)
+		return
+	case deviceCodeGrantType:
+		s.deviceCodeGrant(w, r, clientID, clientSecret)
+		return
+	default:
 		http.Error(w, "Unsupported grant type", http.StatusBadRequest)
 		return
 	}
@@ -493,6 +727,11 @@ func (s *AuthServer) tokenHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if grantType == "refresh_token" {
+		s.refreshTokenGrant(w, r, clientID)
+		return
+	}
+
 	// 查找授权码
 	authCode, exists := s.authCodes[code]
 	if !exists {
@@ -519,55 +758,183 @@ func (s *AuthServer) tokenHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	expirationTime := time.Now().Add(time.Hour)
-	claims := &JwtCustomClaims{
-		UserID:   authCode.UserID,
-		ClientID: clientID,
-		Scope:    authCode.Scope,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expirationTime),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			Issuer:    "http://localhost",
-			Subject:   authCode.UserID,
-		},
+	// 授权请求带有code_challenge时必须提供匹配的code_verifier
+	if authCode.CodeChallenge != "" &&
+		!verifyCodeChallenge(authCode.CodeChallenge, authCode.CodeChallengeMethod, r.FormValue("code_verifier")) {
+		delete(s.authCodes, code)
+		writeOAuthError(w, http.StatusBadRequest, "invalid_grant", "code_verifier does not match code_challenge")
+		return
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 
-	// 生成访问令牌
-	accessToken, err := token.SignedString(s.jwtSecret)
+	// 授予的scope为授权码scope与客户端当前允许范围的交集
+	scope := client.grantableScope(authCode.Scope)
+
+	// 生成访问令牌和刷新令牌
+	accessToken, err := s.mintAccessToken(authCode.UserID, clientID, scope)
+	if err == nil {
+		err = s.mintRefreshToken(accessToken, scope)
+	}
+	if err == nil {
+		err = s.mintIDToken(accessToken, authCode.Nonce)
+	}
 	if err != nil {
 		http.Error(w, "Token generation error", http.StatusInternalServerError)
 		return
 	}
 
+	// 清理已使用的授权码
+	delete(s.authCodes, code)
+
+	// 返回令牌响应
+	writeTokenResponse(w, accessToken)
+}
+
+// refreshTokenGrant 处理 grant_type=refresh_token：校验刷新令牌后签发新的访问令牌，
+// 并轮换刷新令牌，旧令牌立即作废，重复使用或过期时返回invalid_grant
+func (s *AuthServer) refreshTokenGrant(w http.ResponseWriter, r *http.Request, clientID string) {
+	token := r.FormValue("refresh_token")
+	refresh, exists := s.refreshTokens[token]
+	if !exists {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_grant", "refresh token is invalid or already used")
+		return
+	}
+	if refresh.ClientID != clientID {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_grant", "refresh token was issued to another client")
+		return
+	}
+	delete(s.refreshTokens, token)
+	if time.Now().After(refresh.ExpiresAt) {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_grant", "refresh token expired")
+		return
+	}
+
+	// 可以请求原scope的子集
+	scope := refresh.Scope
+	if requested := r.FormValue("scope"); requested != "" {
+		if !scopeCovers(refresh.Scope, requested) {
+			writeOAuthError(w, http.StatusBadRequest, "invalid_scope", "requested scope exceeds the original grant")
+			return
+		}
+		scope = requested
+	}
+
+	accessToken, err := s.mintAccessToken(refresh.UserID, clientID, scope)
+	if err == nil {
+		// 新刷新令牌保留原始授权范围
+		err = s.mintRefreshToken(accessToken, refresh.Scope)
+	}
+	if err == nil {
+		err = s.mintIDToken(accessToken, "")
+	}
+	if err != nil {
+		http.Error(w, "Token generation error", http.StatusInternalServerError)
+		return
+	}
+	writeTokenResponse(w, accessToken)
+}
+
+// clientCredentialsGrant 处理 grant_type=client_credentials：客户端以自身身份获取访问令牌，
+// 令牌不关联用户，sub为客户端ID，scope不能超出客户端允许的范围
+func (s *AuthServer) clientCredentialsGrant(w http.ResponseWriter, r *http.Request, clientID, clientSecret string) {
+	client, exists := s.clients[clientID]
+	// 公开客户端（无密钥）不能使用该授权类型
+	if !exists || client.Secret == "" || client.Secret != clientSecret {
+		writeOAuthError(w, http.StatusUnauthorized, "unauthorized_client", "invalid client credentials")
+		return
+	}
+
+	scope := r.FormValue("scope")
+	if scope == "" {
+		scope = strings.Join(client.AllowedScopes, " ")
+	} else if !client.allowsAllScopes(scope) {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_scope", "requested scope exceeds what the client is allowed")
+		return
+	}
+
+	accessToken, err := s.mintAccessToken("", clientID, scope)
+	if err != nil {
+		http.Error(w, "Token generation error", http.StatusInternalServerError)
+		return
+	}
+	writeTokenResponse(w, accessToken)
+}
+
+// mintRefreshToken 为访问令牌签发并存储刷新令牌，scope为刷新时可请求的最大范围
+func (s *AuthServer) mintRefreshToken(token *AccessToken, scope string) error {
+	refresh, err := generateRandomString(32)
+	if err != nil {
+		return err
+	}
+	s.refreshTokens[refresh] = &RefreshToken{
+		Token:     refresh,
+		ClientID:  token.ClientID,
+		UserID:    token.UserID,
+		Scope:     scope,
+		ExpiresAt: time.Now().Add(refreshTokenTTL),
+	}
+	token.RefreshToken = refresh
+	return nil
+}
+
+// mintAccessToken 签发并存储访问令牌，userID为空（client_credentials）时sub为客户端ID
+func (s *AuthServer) mintAccessToken(userID, clientID, scope string) (*AccessToken, error) {
+	subject := userID
+	if subject == "" {
+		subject = clientID
+	}
+	claims := JwtCustomClaims{
+		UserID:   userID,
+		ClientID: clientID,
+		Scope:    scope,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:  s.issuer,
+			Subject: subject,
+		},
+	}
+	accessToken, err := s.signingKey.issue(claims, accessTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+
 	// 存储访问令牌
 	cachedToken := &AccessToken{
 		Token:     accessToken,
 		Type:      "Bearer",
-		ExpiresIn: 3600, // 1小时有效期
-		Scope:     authCode.Scope,
-		UserID:    authCode.UserID,
+		ExpiresIn: int64(accessTokenTTL / time.Second),
+		ExpiresAt: time.Now().Add(accessTokenTTL),
+		Scope:     scope,
+		UserID:    userID,
 		ClientID:  clientID,
 	}
 	s.accessTokens[accessToken] = cachedToken
 
-	// 清理已使用的授权码
-	delete(s.authCodes, code)
-
-	log.Printf("Generated token for user %s: %s", authCode.UserID, accessToken)
+	log.Printf("Generated token for %s: %s", subject, accessToken)
+	return cachedToken, nil
+}
 
-	// 返回令牌响应
+// writeTokenResponse 输出令牌端点的标准JSON响应
+func writeTokenResponse(w http.ResponseWriter, token *AccessToken) {
+	resp := map[string]interface{}{
+		"access_token": token.Token,
+		"token_type":   token.Type,
+		"expires_in":   token.ExpiresIn,
+		"scope":        token.Scope,
+	}
+	if token.RefreshToken != "" {
+		resp["refresh_token"] = token.RefreshToken
+	}
+	if token.IDToken != "" {
+		resp["id_token"] = token.IDToken
+	}
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"access_token": accessToken,
-		"token_type":   "Bearer",
-		"expires_in":   3600,
-		"scope":        authCode.Scope,
-	})
+	json.NewEncoder(w).Encode(resp)
 }
 
 // 用户信息端点处理器
 func (s *AuthServer) userInfoHandler(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	if r.Method != "GET" {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
@@ -585,30 +952,50 @@ func (s *AuthServer) userInfoHandler(w http.ResponseWriter, r *http.Request) {
 		accessToken = authHeader[7:]
 	}
 
-	token, exists := s.accessTokens[accessToken]
-
-	if !exists {
-		http.Error(w, "Invalid access token", http.StatusUnauthorized)
+	// 校验JWT签名与exp，并检查令牌是否仍有效
+	token, _, err := s.checkAccessToken(accessToken)
+	if err != nil {
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+		writeOAuthError(w, http.StatusUnauthorized, "invalid_token", err.Error())
 		return
 	}
 
-	// 检查令牌是否过期（简化处理，实际应该检查时间）
 	user, exists := s.users[token.UserID]
 	if !exists {
 		http.Error(w, "User not found", http.StatusInternalServerError)
 		return
 	}
 
-	// 返回用户信息
+	// 返回token授权scope允许的用户信息
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"sub":  user.ID,
-		"name": user.Username,
-	})
+	json.NewEncoder(w).Encode(userClaims(user, token.Scope))
+}
+
+// checkAccessToken 校验访问令牌的签名与exp，并确认服务端仍保存该令牌（未被撤销），过期的记录会被清除
+func (s *AuthServer) checkAccessToken(tokenString string) (*AccessToken, *JwtCustomClaims, error) {
+	claims, err := s.signingKey.verify(tokenString)
+	if err != nil {
+		if token, exists := s.accessTokens[tokenString]; exists && token.expired() {
+			delete(s.accessTokens, tokenString)
+		}
+		return nil, nil, err
+	}
+	token, exists := s.accessTokens[tokenString]
+	if !exists {
+		return nil, nil, errors.New("unknown or revoked access token")
+	}
+	if token.expired() {
+		delete(s.accessTokens, tokenString)
+		return nil, nil, errors.New("access token expired")
+	}
+	return token, claims, nil
 }
 
 // verifyHandler 验证JWT Token的接口
 func (s *AuthServer) verifyTokenHandler(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	// 支持GET和POST请求
 	if r.Method != "GET" && r.Method != "POST" {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -638,15 +1025,8 @@ func (s *AuthServer) verifyTokenHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// 解析和验证Token
-	claims := &JwtCustomClaims{}
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		// 验证签名方法
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return s.jwtSecret, nil
-	})
+	// 解析和验证Token，签名有效时再检查令牌是否过期或已撤销
+	_, claims, err := s.checkAccessToken(tokenString)
 
 	// 处理验证结果
 	response := map[string]interface{}{}
@@ -654,10 +1034,6 @@ func (s *AuthServer) verifyTokenHandler(w http.ResponseWriter, r *http.Request)
 		response["valid"] = false
 		response["error"] = err.Error()
 		w.WriteHeader(http.StatusUnauthorized)
-	} else if !token.Valid {
-		response["valid"] = false
-		response["error"] = "Invalid token"
-		w.WriteHeader(http.StatusUnauthorized)
 	} else {
 		response["valid"] = true
 		response["user_id"] = claims.UserID
@@ -0,0 +1,38 @@
+package oauth
+
+import "strings"
+
+// userClaims 返回token授权scope允许公开的用户声明，sub与额外声明始终返回
+func userClaims(user *User, scope string) map[string]interface{} {
+	claims := map[string]interface{}{
+		"sub": user.ID,
+	}
+	for _, sc := range strings.Fields(scope) {
+		switch sc {
+		case "profile":
+			claims["preferred_username"] = user.Username
+			setIfNotEmpty(claims, "name", user.Name)
+			setIfNotEmpty(claims, "given_name", user.GivenName)
+			setIfNotEmpty(claims, "family_name", user.FamilyName)
+		case "email":
+			setIfNotEmpty(claims, "email", user.Email)
+			if user.Email != "" {
+				claims["email_verified"] = user.EmailVerified
+			}
+		case "phone":
+			setIfNotEmpty(claims, "phone_number", user.PhoneNumber)
+		}
+	}
+	for k, v := range user.Claims {
+		if _, exists := claims[k]; !exists {
+			claims[k] = v
+		}
+	}
+	return claims
+}
+
+func setIfNotEmpty(claims map[string]interface{}, key, value string) {
+	if value != "" {
+		claims[key] = value
+	}
+}
@@ -0,0 +1,39 @@
+package oauth
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestClientCredentialsGrant(t *testing.T) {
+	s, mux := newTestServer(t)
+	s.clients["svc"] = &Client{ID: "svc", Secret: "svc-secret", AllowedScopes: []string{"read", "write"}}
+	form := func(secret, scope string) url.Values {
+		return url.Values{
+			"grant_type":    {"client_credentials"},
+			"client_id":     {"svc"},
+			"client_secret": {secret},
+			"scope":         {scope},
+		}
+	}
+
+	status, body := postToken(t, mux, form("svc-secret", "read"))
+	if status != http.StatusOK || body["scope"] != "read" || body["refresh_token"] != nil {
+		t.Fatalf("valid request: got %d %v", status, body)
+	}
+	claims, err := s.signingKey.verify(body["access_token"].(string))
+	if err != nil || claims.Subject != "svc" || claims.UserID != "" {
+		t.Errorf("token claims: %+v, %v; want sub=svc without user", claims, err)
+	}
+
+	if status, body = postToken(t, mux, form("wrong", "read")); status != http.StatusUnauthorized || body["error"] != "unauthorized_client" {
+		t.Errorf("bad secret: got %d %v, want 401 unauthorized_client", status, body)
+	}
+	if status, body = postToken(t, mux, form("svc-secret", "read admin")); status != http.StatusBadRequest || body["error"] != "invalid_scope" {
+		t.Errorf("scope beyond allowed: got %d %v, want 400 invalid_scope", status, body)
+	}
+	if status, body = postToken(t, mux, form("svc-secret", "")); status != http.StatusOK || body["scope"] != "read write" {
+		t.Errorf("default scope: got %d %v, want all allowed scopes", status, body)
+	}
+}
@@ -0,0 +1,41 @@
+package oauth
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// 并发执行完整的授权码流程，配合 go test -race 检查状态map的并发访问
+func TestConcurrentAuthorizeAndToken(t *testing.T) {
+	s, mux := newTestServer(t)
+	client := s.clients["client1"]
+
+	t.Run("group", func(t *testing.T) {
+		for i := 0; i < 16; i++ {
+			t.Run(fmt.Sprint(i), func(t *testing.T) {
+				t.Parallel()
+				code := authorizeCode(t, s, mux, nil)
+				status, body := postToken(t, mux, url.Values{
+					"grant_type":    {"authorization_code"},
+					"code":          {code},
+					"redirect_uri":  {client.RedirectURIs[0]},
+					"client_id":     {client.ID},
+					"client_secret": {client.Secret},
+				})
+				if status != http.StatusOK {
+					t.Fatalf("token: got %d %v", status, body)
+				}
+				req := httptest.NewRequest("GET", "/userinfo", nil)
+				req.Header.Set("Authorization", "Bearer "+body["access_token"].(string))
+				rec := httptest.NewRecorder()
+				mux.ServeHTTP(rec, req)
+				if rec.Code != http.StatusOK {
+					t.Errorf("userinfo: got %d %s", rec.Code, rec.Body)
+				}
+			})
+		}
+	})
+}
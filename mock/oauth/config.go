@@ -0,0 +1,55 @@
+package oauth
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// fileConfig 客户端与用户配置文件的格式
+type fileConfig struct {
+	Clients []*Client `json:"clients" yaml:"clients"`
+	Users   []*User   `json:"users" yaml:"users"`
+}
+
+// LoadConfigFile 从JSON或YAML文件（按扩展名.yaml/.yml区分）读取客户端与用户，
+// 用户ID为空时使用用户名
+func LoadConfigFile(path string) ([]*Client, []*User, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read oauth config: %w", err)
+	}
+
+	var cfg fileConfig
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(b, &cfg)
+	default:
+		err = json.Unmarshal(b, &cfg)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("parse oauth config %s: %w", path, err)
+	}
+
+	for i, c := range cfg.Clients {
+		if c == nil || c.ID == "" {
+			return nil, nil, fmt.Errorf("oauth config %s: client %d has no id", path, i+1)
+		}
+		if m := c.RedirectMatch; m != "" && m != redirectMatchExact && m != redirectMatchPrefix {
+			return nil, nil, fmt.Errorf("oauth config %s: client %s has unknown redirect_match %q", path, c.ID, m)
+		}
+	}
+	for i, u := range cfg.Users {
+		if u == nil || u.Username == "" {
+			return nil, nil, fmt.Errorf("oauth config %s: user %d has no username", path, i+1)
+		}
+		if u.ID == "" {
+			u.ID = u.Username
+		}
+	}
+	return cfg.Clients, cfg.Users, nil
+}
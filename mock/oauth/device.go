@@ -0,0 +1,223 @@
+package oauth
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// deviceCodeGrantType 设备授权模式（RFC 8628）在令牌端点使用的grant_type
+const deviceCodeGrantType = "urn:ietf:params:oauth:grant-type:device_code"
+
+const (
+	// deviceCodeTTL 设备码有效期
+	deviceCodeTTL = 10 * time.Minute
+	// devicePollInterval 客户端轮询令牌端点的最小间隔（秒）
+	devicePollInterval = 5
+	// userCodeChars 用户码字符集，去掉元音和易混淆字符
+	userCodeChars = "BCDFGHJKLMNPQRSTVWXZ"
+)
+
+// 设备授权请求，用户在 /device 页面输入用户码批准或拒绝
+type DeviceRequest struct {
+	DeviceCode string
+	UserCode   string
+	ClientID   string
+	Scope      string
+	UserID     string // 批准的用户，为空表示尚未批准
+	Denied     bool
+	ExpiresAt  time.Time
+	LastPoll   time.Time
+}
+
+// generateUserCode 生成形如 BCDF-GHJK 的用户码
+func generateUserCode() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	code := make([]byte, 0, 9)
+	for i, c := range b {
+		if i == 4 {
+			code = append(code, '-')
+		}
+		code = append(code, userCodeChars[int(c)%len(userCodeChars)])
+	}
+	return string(code), nil
+}
+
+// normalizeUserCode 忽略用户输入中的大小写、空格和连字符
+func normalizeUserCode(code string) string {
+	code = strings.ToUpper(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+// findDeviceRequest 按用户码查找未过期的设备授权请求
+func (s *AuthServer) findDeviceRequest(userCode string) (*DeviceRequest, bool) {
+	userCode = normalizeUserCode(userCode)
+	for _, req := range s.deviceRequests {
+		if normalizeUserCode(req.UserCode) == userCode && time.Now().Before(req.ExpiresAt) {
+			return req, true
+		}
+	}
+	return nil, false
+}
+
+// deviceAuthorizationHandler 设备授权端点，为客户端签发设备码和用户码
+func (s *AuthServer) deviceAuthorizationHandler(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_request", "invalid form body")
+		return
+	}
+
+	// 有密钥的客户端必须提供正确的密钥
+	clientID := r.FormValue("client_id")
+	client, exists := s.clients[clientID]
+	if !exists || (client.Secret != "" && client.Secret != r.FormValue("client_secret")) {
+		writeOAuthError(w, http.StatusUnauthorized, "invalid_client", "invalid client credentials")
+		return
+	}
+
+	scope := r.FormValue("scope")
+	if !client.allowsAllScopes(scope) {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_scope", "requested scope is not allowed for the client")
+		return
+	}
+
+	deviceCode, err := generateRandomString(32)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	userCode, err := generateUserCode()
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	s.deviceRequests[deviceCode] = &DeviceRequest{
+		DeviceCode: deviceCode,
+		UserCode:   userCode,
+		ClientID:   clientID,
+		Scope:      scope,
+		ExpiresAt:  time.Now().Add(deviceCodeTTL),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"device_code":               deviceCode,
+		"user_code":                 userCode,
+		"verification_uri":          s.issuer + "/device",
+		"verification_uri_complete": s.issuer + "/device?user_code=" + userCode,
+		"expires_in":                int(deviceCodeTTL.Seconds()),
+		"interval":                  devicePollInterval,
+	})
+}
+
+// deviceHandler 设备验证页面，已登录用户输入用户码后批准或拒绝设备请求，
+// 未登录时先跳转登录页，登录后回到本页面
+func (s *AuthServer) deviceHandler(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	userCode := r.FormValue("user_code")
+
+	// 检查会话
+	var userID string
+	if cookie, err := r.Cookie("oauth_session"); err == nil {
+		userID = s.sessions[cookie.Value]
+	}
+	if userID == "" {
+		http.Redirect(w, r, "/login?device=1&user_code="+url.QueryEscape(userCode), http.StatusFound)
+		return
+	}
+
+	data := map[string]interface{}{
+		"User":     s.users[userID],
+		"UserCode": userCode,
+	}
+	if userCode != "" {
+		req, exists := s.findDeviceRequest(userCode)
+		if !exists {
+			data["Message"] = "无效或已过期的用户码"
+		} else if r.Method == "POST" && r.FormValue("decision") != "" {
+			// 处理授权决定
+			if r.FormValue("decision") == "allow" {
+				req.UserID = userID
+				data["Message"] = "设备已授权，请返回设备继续操作"
+			} else {
+				req.Denied = true
+				data["Message"] = "已拒绝设备的授权请求"
+			}
+			data["Done"] = true
+		} else {
+			data["Request"] = req
+			data["Client"] = s.clients[req.ClientID]
+		}
+	}
+
+	if err := s.templates.ExecuteTemplate(w, "device.html", data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// deviceCodeGrant 处理设备码换取令牌：用户批准前返回authorization_pending，
+// 轮询过快返回slow_down，拒绝或过期后设备码作废
+func (s *AuthServer) deviceCodeGrant(w http.ResponseWriter, r *http.Request, clientID, clientSecret string) {
+	client, exists := s.clients[clientID]
+	if !exists || (client.Secret != "" && client.Secret != clientSecret) {
+		writeOAuthError(w, http.StatusUnauthorized, "invalid_client", "invalid client credentials")
+		return
+	}
+
+	deviceCode := r.FormValue("device_code")
+	req, exists := s.deviceRequests[deviceCode]
+	if !exists || req.ClientID != clientID {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_grant", "device code is invalid")
+		return
+	}
+	if time.Now().After(req.ExpiresAt) {
+		delete(s.deviceRequests, deviceCode)
+		writeOAuthError(w, http.StatusBadRequest, "expired_token", "device code expired")
+		return
+	}
+	if req.Denied {
+		delete(s.deviceRequests, deviceCode)
+		writeOAuthError(w, http.StatusBadRequest, "access_denied", "the user denied the request")
+		return
+	}
+	if req.UserID == "" {
+		lastPoll := req.LastPoll
+		req.LastPoll = time.Now()
+		if !lastPoll.IsZero() && req.LastPoll.Sub(lastPoll) < devicePollInterval*time.Second {
+			writeOAuthError(w, http.StatusBadRequest, "slow_down", "polling too frequently")
+			return
+		}
+		writeOAuthError(w, http.StatusBadRequest, "authorization_pending", "the user has not yet approved the request")
+		return
+	}
+
+	accessToken, err := s.mintAccessToken(req.UserID, clientID, req.Scope)
+	if err == nil {
+		err = s.mintRefreshToken(accessToken, req.Scope)
+	}
+	if err == nil {
+		err = s.mintIDToken(accessToken, "")
+	}
+	if err != nil {
+		http.Error(w, "Token generation error", http.StatusInternalServerError)
+		return
+	}
+	delete(s.deviceRequests, deviceCode)
+	writeTokenResponse(w, accessToken)
+}
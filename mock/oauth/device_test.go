@@ -0,0 +1,70 @@
+package oauth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDeviceCodeFlow(t *testing.T) {
+	s, mux := newTestServer(t)
+	client := s.clients["client1"]
+
+	req := httptest.NewRequest("POST", "/device_authorization", strings.NewReader(url.Values{
+		"client_id":     {client.ID},
+		"client_secret": {client.Secret},
+		"scope":         {"openid profile"},
+	}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	var auth map[string]interface{}
+	json.Unmarshal(rec.Body.Bytes(), &auth)
+	deviceCode, _ := auth["device_code"].(string)
+	userCode, _ := auth["user_code"].(string)
+	if rec.Code != http.StatusOK || deviceCode == "" || userCode == "" || auth["verification_uri"] != s.issuer+"/device" {
+		t.Fatalf("device_authorization: got %d %v", rec.Code, auth)
+	}
+
+	poll := func() (int, map[string]interface{}) {
+		return postToken(t, mux, url.Values{
+			"grant_type":    {deviceCodeGrantType},
+			"device_code":   {deviceCode},
+			"client_id":     {client.ID},
+			"client_secret": {client.Secret},
+		})
+	}
+	if status, body := poll(); status != http.StatusBadRequest || body["error"] != "authorization_pending" {
+		t.Fatalf("before approval: got %d %v, want authorization_pending", status, body)
+	}
+	if status, body := poll(); status != http.StatusBadRequest || body["error"] != "slow_down" {
+		t.Errorf("fast polling: got %d %v, want slow_down", status, body)
+	}
+
+	// 已登录用户输入小写、不带连字符的用户码批准
+	s.sessions["session1"] = "user1"
+	req = httptest.NewRequest("POST", "/device", strings.NewReader(url.Values{
+		"user_code": {strings.ToLower(strings.ReplaceAll(userCode, "-", ""))},
+		"decision":  {"allow"},
+	}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.AddCookie(&http.Cookie{Name: "oauth_session", Value: "session1"})
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("approve: got %d %s", rec.Code, rec.Body)
+	}
+
+	s.deviceRequests[deviceCode].LastPoll = time.Time{}
+	status, body := poll()
+	if status != http.StatusOK || body["access_token"] == nil || body["scope"] != "openid profile" {
+		t.Fatalf("after approval: got %d %v", status, body)
+	}
+	if status, body = poll(); status != http.StatusBadRequest || body["error"] != "invalid_grant" {
+		t.Errorf("reused device code: got %d %v, want invalid_grant", status, body)
+	}
+}
@@ -0,0 +1,44 @@
+package oauth
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// supportedScopes 发现文档中公布的scope，对应userinfo可返回的声明
+var supportedScopes = []string{"openid", "profile", "email", "phone"}
+
+// discoveryHandler 返回OIDC发现文档（/.well-known/openid-configuration），
+// 各端点地址由issuer拼接，issuer与签发JWT时使用的一致
+func (s *AuthServer) discoveryHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	doc := map[string]interface{}{
+		"issuer":                                s.issuer,
+		"authorization_endpoint":                s.issuer + "/authorize",
+		"token_endpoint":                        s.issuer + "/token",
+		"userinfo_endpoint":                     s.issuer + "/userinfo",
+		"jwks_uri":                              s.issuer + "/jwks.json",
+		"revocation_endpoint":                   s.issuer + "/revoke",
+		"introspection_endpoint":                s.issuer + "/introspect",
+		"device_authorization_endpoint":         s.issuer + "/device_authorization",
+		"end_session_endpoint":                  s.issuer + "/logout",
+		"response_types_supported":              []string{"code"},
+		"response_modes_supported":              []string{responseModeQuery, responseModeFormPost},
+		"grant_types_supported":                 []string{"authorization_code", "refresh_token", "client_credentials", deviceCodeGrantType},
+		"scopes_supported":                      supportedScopes,
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{s.signingKey.method.Alg()},
+		"token_endpoint_auth_methods_supported": []string{"client_secret_post", "client_secret_basic"},
+		"code_challenge_methods_supported":      []string{"S256", "plain"},
+		"claims_supported": []string{
+			"sub", "preferred_username", "name", "given_name", "family_name",
+			"email", "email_verified", "phone_number",
+		},
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(doc)
+}
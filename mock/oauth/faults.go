@@ -0,0 +1,155 @@
+package oauth
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+)
+
+// errorCatalog 支持注入的OAuth错误码及其默认HTTP状态码
+var errorCatalog = map[string]int{
+	"invalid_request":           http.StatusBadRequest,
+	"invalid_client":            http.StatusUnauthorized,
+	"invalid_grant":             http.StatusBadRequest,
+	"unauthorized_client":       http.StatusBadRequest,
+	"unsupported_grant_type":    http.StatusBadRequest,
+	"invalid_scope":             http.StatusBadRequest,
+	"authorization_pending":     http.StatusBadRequest,
+	"slow_down":                 http.StatusBadRequest,
+	"expired_token":             http.StatusBadRequest,
+	"access_denied":             http.StatusForbidden,
+	"invalid_token":             http.StatusUnauthorized,
+	"insufficient_scope":        http.StatusForbidden,
+	"server_error":              http.StatusInternalServerError,
+	"temporarily_unavailable":   http.StatusServiceUnavailable,
+	"unsupported_response_type": http.StatusBadRequest,
+}
+
+// injectedFault 对某个端点的后续Count次请求返回指定错误
+type injectedFault struct {
+	Endpoint    string `json:"endpoint"`
+	Error       string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+	Status      int    `json:"status,omitempty"`
+	Count       int    `json:"count"`
+}
+
+// faultInjector 按端点保存待注入的错误
+type faultInjector struct {
+	mu     sync.Mutex
+	faults map[string]*injectedFault
+}
+
+func newFaultInjector() *faultInjector {
+	return &faultInjector{faults: make(map[string]*injectedFault)}
+}
+
+// take 若端点有待注入的错误则消耗一次并返回
+func (f *faultInjector) take(endpoint string) (injectedFault, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	fault, exists := f.faults[endpoint]
+	if !exists {
+		return injectedFault{}, false
+	}
+	fault.Count--
+	if fault.Count <= 0 {
+		delete(f.faults, endpoint)
+	}
+	return *fault, true
+}
+
+func (f *faultInjector) set(fault *injectedFault) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.faults[fault.Endpoint] = fault
+}
+
+func (f *faultInjector) list() []injectedFault {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	result := make([]injectedFault, 0, len(f.faults))
+	for _, fault := range f.faults {
+		result = append(result, *fault)
+	}
+	return result
+}
+
+func (f *faultInjector) clear(endpoint string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if endpoint == "" {
+		f.faults = make(map[string]*injectedFault)
+		return
+	}
+	delete(f.faults, endpoint)
+}
+
+// withFaults 包装端点处理器，存在待注入的错误时直接返回该错误响应
+func (s *AuthServer) withFaults(endpoint string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if fault, ok := s.faults.take(endpoint); ok {
+			writeOAuthError(w, fault.Status, fault.Error, fault.Description)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// writeOAuthError 输出RFC 6749格式的错误响应
+func writeOAuthError(w http.ResponseWriter, status int, errCode, description string) {
+	body := map[string]string{"error": errCode}
+	if description != "" {
+		body["error_description"] = description
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// adminErrorsHandler 管理错误注入：GET列出，POST设置，DELETE清除（可带endpoint参数）
+func (s *AuthServer) adminErrorsHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.checkAdmin(w, r) {
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(s.faults.list())
+	case "POST":
+		var fault injectedFault
+		if err := json.NewDecoder(r.Body).Decode(&fault); err != nil {
+			http.Error(w, "Invalid input", http.StatusBadRequest)
+			return
+		}
+		defaultStatus, known := errorCatalog[fault.Error]
+		if !known {
+			http.Error(w, "Unknown error code: "+fault.Error, http.StatusBadRequest)
+			return
+		}
+		if fault.Endpoint == "" {
+			http.Error(w, "endpoint is required", http.StatusBadRequest)
+			return
+		}
+		if fault.Status == 0 {
+			fault.Status = defaultStatus
+		}
+		if fault.Count <= 0 {
+			fault.Count = 1
+		}
+		s.faults.set(&fault)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(fault)
+	case "DELETE":
+		s.faults.clear(r.URL.Query().Get("endpoint"))
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,76 @@
+package oauth
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// authenticateClient 校验客户端凭据，支持HTTP Basic和表单中的client_id/client_secret
+func (s *AuthServer) authenticateClient(r *http.Request) (*Client, bool) {
+	clientID, clientSecret, ok := r.BasicAuth()
+	if !ok {
+		clientID, clientSecret = r.FormValue("client_id"), r.FormValue("client_secret")
+	}
+	client, exists := s.clients[clientID]
+	if !exists || client.Secret == "" || subtle.ConstantTimeCompare([]byte(client.Secret), []byte(clientSecret)) != 1 {
+		return nil, false
+	}
+	return client, true
+}
+
+// introspectHandler 令牌自省端点（RFC 7662），需要客户端认证；
+// 未知、过期或已撤销的令牌返回 {"active": false}
+func (s *AuthServer) introspectHandler(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_request", "invalid form body")
+		return
+	}
+	if _, ok := s.authenticateClient(r); !ok {
+		w.Header().Set("WWW-Authenticate", `Basic realm="introspect"`)
+		writeOAuthError(w, http.StatusUnauthorized, "invalid_client", "client authentication required")
+		return
+	}
+
+	token := r.FormValue("token")
+	resp := map[string]interface{}{"active": false}
+	if access, claims, err := s.checkAccessToken(token); err == nil {
+		resp = map[string]interface{}{
+			"active":     true,
+			"scope":      access.Scope,
+			"client_id":  access.ClientID,
+			"token_type": access.Type,
+			"sub":        claims.Subject,
+			"iss":        claims.Issuer,
+			"exp":        claims.ExpiresAt.Unix(),
+			"iat":        claims.IssuedAt.Unix(),
+		}
+		if user, exists := s.users[access.UserID]; exists {
+			resp["username"] = user.Username
+		}
+	} else if refresh, exists := s.refreshTokens[token]; exists && time.Now().Before(refresh.ExpiresAt) {
+		resp = map[string]interface{}{
+			"active":     true,
+			"scope":      refresh.Scope,
+			"client_id":  refresh.ClientID,
+			"token_type": "refresh_token",
+			"sub":        refresh.UserID,
+			"exp":        refresh.ExpiresAt.Unix(),
+		}
+		if user, exists := s.users[refresh.UserID]; exists {
+			resp["username"] = user.Username
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,49 @@
+package oauth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIntrospect(t *testing.T) {
+	s, mux := newTestServer(t)
+	client := s.clients["client1"]
+	token, err := s.mintAccessToken("user1", "client1", "openid profile")
+	if err != nil {
+		t.Fatalf("mintAccessToken: %v", err)
+	}
+	introspect := func(secret, tok string) (int, map[string]interface{}) {
+		req := httptest.NewRequest("POST", "/introspect", strings.NewReader(url.Values{"token": {tok}}.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetBasicAuth(client.ID, secret)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		var body map[string]interface{}
+		json.Unmarshal(rec.Body.Bytes(), &body)
+		return rec.Code, body
+	}
+
+	status, body := introspect(client.Secret, token.Token)
+	if status != http.StatusOK || body["active"] != true {
+		t.Fatalf("active token: got %d %v", status, body)
+	}
+	if body["client_id"] != "client1" || body["scope"] != "openid profile" || body["sub"] != "user1" || body["username"] == nil {
+		t.Errorf("active token fields: %v", body)
+	}
+
+	if status, body = introspect("wrong", token.Token); status != http.StatusUnauthorized || body["error"] != "invalid_client" {
+		t.Errorf("bad client secret: got %d %v, want 401 invalid_client", status, body)
+	}
+	if status, body = introspect(client.Secret, "unknown"); status != http.StatusOK || len(body) != 1 || body["active"] != false {
+		t.Errorf("unknown token: got %d %v, want {active:false}", status, body)
+	}
+	token.ExpiresAt = time.Now().Add(-time.Second)
+	if status, body = introspect(client.Secret, token.Token); status != http.StatusOK || body["active"] != false {
+		t.Errorf("expired token: got %d %v, want {active:false}", status, body)
+	}
+}
@@ -0,0 +1,77 @@
+package oauth
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// parseIDTokenHint 校验id_token_hint的签名，过期的id_token同样接受
+func (s *AuthServer) parseIDTokenHint(hint string) (*idTokenClaims, bool) {
+	claims := &idTokenClaims{}
+	_, err := jwt.ParseWithClaims(hint, claims, func(*jwt.Token) (interface{}, error) {
+		return s.signingKey.public, nil
+	}, jwt.WithValidMethods([]string{s.signingKey.method.Alg()}), jwt.WithoutClaimsValidation())
+	return claims, err == nil
+}
+
+// logoutHandler RP发起的登出端点（OIDC end_session_endpoint），清除会话后
+// 重定向到客户端已登记的post_logout_redirect_uri，未登记时回到首页
+func (s *AuthServer) logoutHandler(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	clientID := r.FormValue("client_id")
+	var hintSubject string
+	if hint := r.FormValue("id_token_hint"); hint != "" {
+		claims, ok := s.parseIDTokenHint(hint)
+		if !ok {
+			http.Error(w, "Invalid id_token_hint", http.StatusBadRequest)
+			return
+		}
+		hintSubject = claims.Subject
+		if clientID == "" && len(claims.Audience) > 0 {
+			clientID = claims.Audience[0]
+		}
+	}
+
+	// 清除cookie对应的会话；没有cookie时按id_token_hint的用户清除其全部会话
+	if cookie, err := r.Cookie("oauth_session"); err == nil {
+		delete(s.sessions, cookie.Value)
+	} else if hintSubject != "" {
+		for id, userID := range s.sessions {
+			if userID == hintSubject {
+				delete(s.sessions, id)
+			}
+		}
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     "oauth_session",
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+	})
+
+	redirect := "/"
+	if uri := r.FormValue("post_logout_redirect_uri"); uri != "" {
+		if client, exists := s.clients[clientID]; exists {
+			for _, registered := range client.PostLogoutRedirectURIs {
+				if registered != uri {
+					continue
+				}
+				if u, err := url.Parse(uri); err == nil {
+					if state := r.FormValue("state"); state != "" {
+						q := u.Query()
+						q.Set("state", state)
+						u.RawQuery = q.Encode()
+					}
+					redirect = u.String()
+				}
+				break
+			}
+		}
+	}
+	http.Redirect(w, r, redirect, http.StatusFound)
+}
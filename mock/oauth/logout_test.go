@@ -0,0 +1,62 @@
+package oauth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestLogout(t *testing.T) {
+	s, mux := newTestServer(t)
+	client := s.clients["client1"]
+	client.PostLogoutRedirectURIs = []string{"http://localhost:3000/logged-out"}
+	logout := func(q url.Values, cookie bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/logout?"+q.Encode(), nil)
+		if cookie {
+			req.AddCookie(&http.Cookie{Name: "oauth_session", Value: "session1"})
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	s.sessions["session1"] = "user1"
+	rec := logout(url.Values{
+		"client_id":                {client.ID},
+		"post_logout_redirect_uri": {"http://localhost:3000/logged-out"},
+		"state":                    {"abc"},
+	}, true)
+	if loc := rec.Header().Get("Location"); loc != "http://localhost:3000/logged-out?state=abc" {
+		t.Errorf("registered redirect: got %q", loc)
+	}
+	if _, exists := s.sessions["session1"]; exists {
+		t.Error("session not deleted")
+	}
+
+	// 未登记的地址回到首页
+	rec = logout(url.Values{"client_id": {client.ID}, "post_logout_redirect_uri": {"http://evil.example"}}, true)
+	if loc := rec.Header().Get("Location"); loc != "/" {
+		t.Errorf("unregistered redirect: got %q, want /", loc)
+	}
+
+	// id_token_hint确定客户端与用户
+	token, err := s.mintAccessToken("user1", client.ID, "openid")
+	if err == nil {
+		err = s.mintIDToken(token, "")
+	}
+	if err != nil {
+		t.Fatalf("mint tokens: %v", err)
+	}
+	s.sessions["session2"] = "user1"
+	rec = logout(url.Values{
+		"id_token_hint":            {token.IDToken},
+		"post_logout_redirect_uri": {"http://localhost:3000/logged-out"},
+	}, false)
+	if loc := rec.Header().Get("Location"); loc != "http://localhost:3000/logged-out" {
+		t.Errorf("id_token_hint redirect: got %q", loc)
+	}
+	if _, exists := s.sessions["session2"]; exists {
+		t.Error("session of the id_token_hint subject not deleted")
+	}
+}
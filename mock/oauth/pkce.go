@@ -0,0 +1,29 @@
+package oauth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"regexp"
+)
+
+// codeVerifierRe RFC 7636 4.1：43到128个unreserved字符
+var codeVerifierRe = regexp.MustCompile(`^[A-Za-z0-9._~-]{43,128}$`)
+
+// validChallengeMethod 只支持S256和plain，未指定时按plain处理
+func validChallengeMethod(method string) bool {
+	return method == "S256" || method == "plain"
+}
+
+// verifyCodeChallenge 校验code_verifier是否与授权请求中的code_challenge匹配
+func verifyCodeChallenge(challenge, method, verifier string) bool {
+	if !codeVerifierRe.MatchString(verifier) {
+		return false
+	}
+	expected := verifier
+	if method == "S256" {
+		sum := sha256.Sum256([]byte(verifier))
+		expected = base64.RawURLEncoding.EncodeToString(sum[:])
+	}
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(challenge)) == 1
+}
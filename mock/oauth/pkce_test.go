@@ -0,0 +1,126 @@
+package oauth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// RFC 7636 附录B的示例
+const (
+	testVerifier  = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	testChallenge = "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+)
+
+func TestVerifyCodeChallenge(t *testing.T) {
+	if !verifyCodeChallenge(testChallenge, "S256", testVerifier) {
+		t.Error("S256: RFC 7636 example verifier rejected")
+	}
+	if !verifyCodeChallenge(testVerifier, "plain", testVerifier) {
+		t.Error("plain: identical verifier rejected")
+	}
+	if verifyCodeChallenge(testChallenge, "S256", strings.Repeat("a", 43)) {
+		t.Error("S256: wrong verifier accepted")
+	}
+	if verifyCodeChallenge("short", "plain", "short") {
+		t.Error("plain: verifier shorter than 43 characters accepted")
+	}
+}
+
+// authorizeCode 以已登录的user1走完授权流程，返回签发的授权码
+func authorizeCode(t *testing.T, s *AuthServer, mux http.Handler, extra url.Values) string {
+	t.Helper()
+	s.mu.Lock()
+	s.sessions["test-session"] = "user1"
+	client := s.clients["client1"]
+	s.mu.Unlock()
+	cookie := &http.Cookie{Name: "oauth_session", Value: "test-session"}
+
+	q := url.Values{
+		"client_id":     {client.ID},
+		"redirect_uri":  {client.RedirectURIs[0]},
+		"response_type": {"code"},
+		"scope":         {"openid"},
+	}
+	for k, v := range extra {
+		q[k] = v
+	}
+	req := httptest.NewRequest("GET", "/authorize?"+q.Encode(), nil)
+	req.AddCookie(cookie)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	loc, _ := url.Parse(rec.Header().Get("Location"))
+	if loc == nil || loc.Path != "/auth" {
+		t.Fatalf("authorize: expected redirect to /auth, got %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+
+	req = httptest.NewRequest("POST", loc.String(), strings.NewReader("decision=allow"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.AddCookie(cookie)
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	loc, _ = url.Parse(rec.Header().Get("Location"))
+	code := loc.Query().Get("code")
+	if code == "" {
+		t.Fatalf("auth: no code in redirect %q", rec.Header().Get("Location"))
+	}
+	return code
+}
+
+// postToken 请求令牌端点，返回状态码和JSON响应
+func postToken(t *testing.T, mux http.Handler, form url.Values) (int, map[string]interface{}) {
+	t.Helper()
+	req := httptest.NewRequest("POST", "/token", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	body := map[string]interface{}{}
+	json.Unmarshal(rec.Body.Bytes(), &body)
+	return rec.Code, body
+}
+
+func newTestServer(t *testing.T) (*AuthServer, *http.ServeMux) {
+	t.Helper()
+	s, err := NewAuthServer(Config{})
+	if err != nil {
+		t.Fatalf("NewAuthServer: %v", err)
+	}
+	mux := http.NewServeMux()
+	s.SetupRoutes(mux)
+	return s, mux
+}
+
+func TestTokenRequiresCodeVerifier(t *testing.T) {
+	s, mux := newTestServer(t)
+	client := s.clients["client1"]
+	form := func(code, verifier string) url.Values {
+		return url.Values{
+			"grant_type":    {"authorization_code"},
+			"code":          {code},
+			"redirect_uri":  {client.RedirectURIs[0]},
+			"client_id":     {client.ID},
+			"client_secret": {client.Secret},
+			"code_verifier": {verifier},
+		}
+	}
+	pkce := url.Values{"code_challenge": {testChallenge}, "code_challenge_method": {"S256"}}
+
+	code := authorizeCode(t, s, mux, pkce)
+	status, body := postToken(t, mux, form(code, strings.Repeat("x", 43)))
+	if status != http.StatusBadRequest || body["error"] != "invalid_grant" {
+		t.Errorf("wrong verifier: got %d %v, want 400 invalid_grant", status, body)
+	}
+	// 校验失败后授权码作废
+	if status, _ := postToken(t, mux, form(code, testVerifier)); status == http.StatusOK {
+		t.Error("code still usable after a failed verification")
+	}
+
+	code = authorizeCode(t, s, mux, pkce)
+	status, body = postToken(t, mux, form(code, testVerifier))
+	if status != http.StatusOK || body["access_token"] == nil {
+		t.Errorf("matching verifier: got %d %v", status, body)
+	}
+}
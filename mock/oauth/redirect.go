@@ -0,0 +1,80 @@
+package oauth
+
+import (
+	"net"
+	"net/url"
+	"strings"
+)
+
+// redirect_uri匹配方式
+const (
+	redirectMatchExact  = "exact"  // 与登记的地址完全一致（默认）
+	redirectMatchPrefix = "prefix" // 以登记的地址为前缀
+)
+
+// matchRedirectURI 按匹配方式比较请求的redirect_uri与登记的地址；
+// 登记地址的端口写为*（如 http://localhost:*/callback）时，回环地址可使用任意端口
+func matchRedirectURI(registered, requested, mode string) bool {
+	if mode == redirectMatchPrefix {
+		return matchRedirectPrefix(registered, requested)
+	}
+	if registered == requested {
+		return true
+	}
+
+	reg, err := url.Parse(strings.Replace(registered, ":*", "", 1))
+	if err != nil || !strings.Contains(registered, ":*") || !isLoopback(reg.Hostname()) {
+		return false
+	}
+	req, err := url.Parse(requested)
+	if err != nil || req.Port() == "" {
+		return false
+	}
+	return req.Scheme == reg.Scheme && req.Hostname() == reg.Hostname() &&
+		req.Path == reg.Path && req.RawQuery == reg.RawQuery
+}
+
+// matchRedirectPrefix 前缀匹配：协议、主机和端口必须一致，路径须在登记路径之下（按路径段划分），
+// 查询参数须与登记地址一致；带用户信息或片段的地址一律拒绝
+func matchRedirectPrefix(registered, requested string) bool {
+	reg, err := url.Parse(registered)
+	if err != nil || reg.Opaque != "" || reg.Host == "" {
+		return false
+	}
+	req, err := url.Parse(requested)
+	if err != nil || req.Opaque != "" || req.User != nil || req.Fragment != "" {
+		return false
+	}
+	if !strings.EqualFold(req.Scheme, reg.Scheme) || !strings.EqualFold(req.Host, reg.Host) {
+		return false
+	}
+	if req.RawQuery != reg.RawQuery {
+		return false
+	}
+	if req.Path == reg.Path {
+		return true
+	}
+	prefix := reg.Path
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return strings.HasPrefix(req.Path, prefix) && !strings.Contains(req.Path, "/../") && !strings.HasSuffix(req.Path, "/..")
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// allowsRedirectURI 请求的redirect_uri是否与客户端登记的某个地址匹配
+func (c *Client) allowsRedirectURI(uri string) bool {
+	for _, registered := range c.RedirectURIs {
+		if matchRedirectURI(registered, uri, c.RedirectMatch) {
+			return true
+		}
+	}
+	return false
+}
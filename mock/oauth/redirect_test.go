@@ -0,0 +1,65 @@
+package oauth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestMatchRedirectURI(t *testing.T) {
+	tests := []struct {
+		registered, requested, mode string
+		want                        bool
+	}{
+		{"http://localhost:8080/cb", "http://localhost:8080/cb", "", true},
+		{"http://localhost:8080/cb", "http://localhost:8080/cb/x", "", false},
+		{"http://localhost:8080/cb", "http://localhost:8080/cb/x", redirectMatchPrefix, true},
+		{"http://localhost:8080/cb", "http://localhost:8080/cbx", redirectMatchPrefix, false},
+		{"http://localhost:8080/cb/", "http://localhost:8080/cb/x", redirectMatchPrefix, true},
+		{"https://app.example.com", "https://app.example.com.evil.net/cb", redirectMatchPrefix, false},
+		{"https://app.example.com", "https://app.example.com@evil.net/cb", redirectMatchPrefix, false},
+		{"https://app.example.com/cb", "https://app.example.com:8443/cb/x", redirectMatchPrefix, false},
+		{"https://app.example.com/cb", "http://app.example.com/cb/x", redirectMatchPrefix, false},
+		{"https://app.example.com/cb", "https://app.example.com/cb/../admin", redirectMatchPrefix, false},
+		{"https://app.example.com/cb", "https://app.example.com/cb/x#frag", redirectMatchPrefix, false},
+		{"http://localhost:*/cb", "http://localhost:53682/cb", "", true},
+		{"http://127.0.0.1:*/cb", "http://127.0.0.1:1234/cb", "", true},
+		{"http://localhost:*/cb", "http://localhost/cb", "", false},
+		{"http://localhost:*/cb", "http://localhost:1234/other", "", false},
+		{"http://localhost:*/cb", "https://localhost:1234/cb", "", false},
+		{"https://app.example.com:*/cb", "https://app.example.com:8443/cb", "", false},
+	}
+	for _, tt := range tests {
+		if got := matchRedirectURI(tt.registered, tt.requested, tt.mode); got != tt.want {
+			t.Errorf("matchRedirectURI(%q, %q, %q) = %v, want %v", tt.registered, tt.requested, tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestAuthorizeErrorResponses(t *testing.T) {
+	s, mux := newTestServer(t)
+	client := s.clients["client1"]
+	authorize := func(redirectURI, responseType string) *httptest.ResponseRecorder {
+		q := url.Values{
+			"client_id":     {client.ID},
+			"redirect_uri":  {redirectURI},
+			"response_type": {responseType},
+			"state":         {"xyz"},
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest("GET", "/authorize?"+q.Encode(), nil))
+		return rec
+	}
+
+	// redirect_uri未通过校验时不能重定向
+	if rec := authorize("http://evil.example/cb", "code"); rec.Code != http.StatusBadRequest {
+		t.Errorf("unregistered redirect_uri: got %d, want 400", rec.Code)
+	}
+
+	rec := authorize(client.RedirectURIs[0], "token")
+	loc, _ := url.Parse(rec.Header().Get("Location"))
+	if rec.Code != http.StatusFound || loc.Query().Get("error") != "unsupported_response_type" || loc.Query().Get("state") != "xyz" {
+		t.Errorf("bad response_type: got %d %q, want unsupported_response_type redirect", rec.Code, rec.Header().Get("Location"))
+	}
+}
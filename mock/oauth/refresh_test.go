@@ -0,0 +1,49 @@
+package oauth
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestRefreshTokenRotation(t *testing.T) {
+	s, mux := newTestServer(t)
+	client := s.clients["client1"]
+	code := authorizeCode(t, s, mux, nil)
+	status, body := postToken(t, mux, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {client.RedirectURIs[0]},
+		"client_id":     {client.ID},
+		"client_secret": {client.Secret},
+	})
+	refresh, _ := body["refresh_token"].(string)
+	if status != http.StatusOK || refresh == "" {
+		t.Fatalf("code grant: got %d %v, want a refresh_token", status, body)
+	}
+
+	refreshForm := func(token string) url.Values {
+		return url.Values{
+			"grant_type":    {"refresh_token"},
+			"refresh_token": {token},
+			"client_id":     {client.ID},
+			"client_secret": {client.Secret},
+		}
+	}
+	status, body = postToken(t, mux, refreshForm(refresh))
+	rotated, _ := body["refresh_token"].(string)
+	if status != http.StatusOK || body["access_token"] == nil || rotated == "" || rotated == refresh {
+		t.Fatalf("refresh: got %d %v, want a new access and refresh token", status, body)
+	}
+
+	// 旧刷新令牌已被轮换作废
+	if status, body = postToken(t, mux, refreshForm(refresh)); status != http.StatusBadRequest || body["error"] != "invalid_grant" {
+		t.Errorf("reused refresh token: got %d %v, want 400 invalid_grant", status, body)
+	}
+
+	s.refreshTokens[rotated].ExpiresAt = time.Now().Add(-time.Second)
+	if status, body = postToken(t, mux, refreshForm(rotated)); status != http.StatusBadRequest || body["error"] != "invalid_grant" {
+		t.Errorf("expired refresh token: got %d %v, want 400 invalid_grant", status, body)
+	}
+}
@@ -0,0 +1,41 @@
+package oauth
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// 授权响应的返回方式（response_mode）
+const (
+	responseModeQuery    = "query"     // 参数附加在redirect_uri的查询串中重定向（默认）
+	responseModeFormPost = "form_post" // 自动提交的HTML表单POST到redirect_uri
+)
+
+// sendAuthResponse 按授权请求的response_mode把授权码或错误返回给客户端，state自动附加
+func (s *AuthServer) sendAuthResponse(w http.ResponseWriter, r *http.Request, authRequest *AuthRequest, params url.Values) {
+	if authRequest.State != "" {
+		params.Set("state", authRequest.State)
+	}
+
+	if authRequest.ResponseMode == responseModeFormPost {
+		data := map[string]interface{}{
+			"RedirectURI": authRequest.RedirectURI,
+			"Params":      params,
+		}
+		w.Header().Set("Cache-Control", "no-store")
+		if err := s.templates.ExecuteTemplate(w, "form_post.html", data); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	redirectURL, _ := url.Parse(authRequest.RedirectURI)
+	query := redirectURL.Query()
+	for k, vs := range params {
+		for _, v := range vs {
+			query.Add(k, v)
+		}
+	}
+	redirectURL.RawQuery = query.Encode()
+	http.Redirect(w, r, redirectURL.String(), http.StatusFound)
+}
@@ -0,0 +1,49 @@
+package oauth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestFormPostResponseMode(t *testing.T) {
+	s, mux := newTestServer(t)
+	client := s.clients["client1"]
+	s.sessions["session1"] = "user1"
+	cookie := &http.Cookie{Name: "oauth_session", Value: "session1"}
+
+	q := url.Values{
+		"client_id":     {client.ID},
+		"redirect_uri":  {client.RedirectURIs[0]},
+		"response_type": {"code"},
+		"response_mode": {"form_post"},
+		"scope":         {"openid"},
+		"state":         {"xyz"},
+	}
+	req := httptest.NewRequest("GET", "/authorize?"+q.Encode(), nil)
+	req.AddCookie(cookie)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	req = httptest.NewRequest("POST", rec.Header().Get("Location"), strings.NewReader("decision=allow"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.AddCookie(cookie)
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	page := rec.Body.String()
+	if rec.Code != http.StatusOK || rec.Header().Get("Location") != "" {
+		t.Fatalf("form_post: got %d, Location %q; want an HTML form", rec.Code, rec.Header().Get("Location"))
+	}
+	for _, want := range []string{
+		`action="` + client.RedirectURIs[0] + `"`,
+		`name="code"`,
+		`name="state" value="xyz"`,
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("form_post page missing %s:\n%s", want, page)
+		}
+	}
+}
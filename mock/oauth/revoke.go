@@ -0,0 +1,57 @@
+package oauth
+
+import "net/http"
+
+// revokeHandler 令牌撤销端点（RFC 7009），需要客户端认证；token可以是访问令牌或刷新令牌，
+// token_type_hint只决定查找顺序；未知令牌或其他客户端的令牌同样返回200
+func (s *AuthServer) revokeHandler(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_request", "invalid form body")
+		return
+	}
+
+	token := r.FormValue("token")
+	if token == "" {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_request", "token is required")
+		return
+	}
+
+	// 需要客户端认证，且只能撤销签发给该客户端的令牌
+	client, ok := s.authenticateClient(r)
+	if !ok {
+		w.Header().Set("WWW-Authenticate", `Basic realm="revoke"`)
+		writeOAuthError(w, http.StatusUnauthorized, "invalid_client", "client authentication required")
+		return
+	}
+
+	revokeAccess := func() bool {
+		t, exists := s.accessTokens[token]
+		if !exists || t.ClientID != client.ID {
+			return false
+		}
+		delete(s.accessTokens, token)
+		return true
+	}
+	revokeRefresh := func() bool {
+		t, exists := s.refreshTokens[token]
+		if !exists || t.ClientID != client.ID {
+			return false
+		}
+		delete(s.refreshTokens, token)
+		return true
+	}
+	if r.FormValue("token_type_hint") == "refresh_token" {
+		_ = revokeRefresh() || revokeAccess()
+	} else {
+		_ = revokeAccess() || revokeRefresh()
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
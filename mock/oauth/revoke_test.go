@@ -0,0 +1,67 @@
+package oauth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestRevokeAccessToken(t *testing.T) {
+	s, mux := newTestServer(t)
+	token, err := s.mintAccessToken("user1", "client1", "openid")
+	if err != nil {
+		t.Fatalf("mintAccessToken: %v", err)
+	}
+	do := func(method, target string, form url.Values) int {
+		var req *http.Request
+		if form != nil {
+			req = httptest.NewRequest(method, target, strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		} else {
+			req = httptest.NewRequest(method, target, nil)
+		}
+		req.Header.Set("Authorization", "Bearer "+token.Token)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := do("GET", "/verify", nil); code != http.StatusOK {
+		t.Fatalf("verify before revocation: %d", code)
+	}
+	// 缺少或错误的客户端凭据返回401，令牌仍然有效
+	if code := do("POST", "/revoke", url.Values{"token": {token.Token}}); code != http.StatusUnauthorized {
+		t.Errorf("revoke without client credentials: got %d, want 401", code)
+	}
+	wrong := url.Values{"token": {token.Token}, "client_id": {"client1"}, "client_secret": {"wrong"}}
+	if code := do("POST", "/revoke", wrong); code != http.StatusUnauthorized {
+		t.Errorf("revoke with wrong secret: got %d, want 401", code)
+	}
+	// 其他客户端不能撤销该令牌
+	s.clients["client2"] = &Client{ID: "client2", Secret: "secret2"}
+	other := url.Values{"token": {token.Token}, "client_id": {"client2"}, "client_secret": {"secret2"}}
+	if code := do("POST", "/revoke", other); code != http.StatusOK {
+		t.Errorf("revoke by another client: got %d, want 200", code)
+	}
+	if code := do("GET", "/verify", nil); code != http.StatusOK {
+		t.Fatalf("verify after rejected revocations: %d", code)
+	}
+
+	form := url.Values{"token": {token.Token}, "client_id": {"client1"}, "client_secret": {"secret1"}}
+	if code := do("POST", "/revoke", form); code != http.StatusOK {
+		t.Fatalf("revoke: %d", code)
+	}
+	if code := do("GET", "/verify", nil); code != http.StatusUnauthorized {
+		t.Errorf("verify after revocation: got %d, want 401", code)
+	}
+	if code := do("GET", "/userinfo", nil); code != http.StatusUnauthorized {
+		t.Errorf("userinfo after revocation: got %d, want 401", code)
+	}
+	// 未知令牌同样返回200
+	form.Set("token", "unknown")
+	if code := do("POST", "/revoke", form); code != http.StatusOK {
+		t.Errorf("revoke unknown token: got %d, want 200", code)
+	}
+}
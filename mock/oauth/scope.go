@@ -0,0 +1,65 @@
+package oauth
+
+import "strings"
+
+// scopeDescriptions 授权页面上各scope的说明，未列出的scope直接显示名称
+var scopeDescriptions = map[string]string{
+	"openid":         "确认您的身份",
+	"profile":        "读取您的基本信息",
+	"email":          "访问您的电子邮件地址",
+	"phone":          "访问您的电话号码",
+	"offline_access": "在您离线时保持访问",
+}
+
+// scopeItem 授权页面中展示的单个scope
+type scopeItem struct {
+	Name        string
+	Description string
+}
+
+// describeScopes 将空格分隔的scope拆分为授权页面展示用的列表
+func describeScopes(scope string) []scopeItem {
+	var items []scopeItem
+	for _, sc := range strings.Fields(scope) {
+		desc, ok := scopeDescriptions[sc]
+		if !ok {
+			desc = sc
+		}
+		items = append(items, scopeItem{Name: sc, Description: desc})
+	}
+	return items
+}
+
+// allowsScope 客户端是否可以申请该scope，AllowedScopes为空时不限制
+func (c *Client) allowsScope(sc string) bool {
+	if len(c.AllowedScopes) == 0 {
+		return true
+	}
+	for _, allowed := range c.AllowedScopes {
+		if allowed == sc {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsAllScopes 请求的每个scope是否都在客户端允许范围内
+func (c *Client) allowsAllScopes(scope string) bool {
+	for _, sc := range strings.Fields(scope) {
+		if !c.allowsScope(sc) {
+			return false
+		}
+	}
+	return true
+}
+
+// grantableScope 返回请求scope与客户端允许范围的交集
+func (c *Client) grantableScope(scope string) string {
+	var granted []string
+	for _, sc := range strings.Fields(scope) {
+		if c.allowsScope(sc) {
+			granted = append(granted, sc)
+		}
+	}
+	return strings.Join(granted, " ")
+}
@@ -0,0 +1,75 @@
+package oauth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestAuthorizeRejectsDisallowedScope(t *testing.T) {
+	s, mux := newTestServer(t)
+	client := s.clients["client1"]
+	client.AllowedScopes = []string{"openid", "profile"}
+
+	q := url.Values{
+		"client_id":     {client.ID},
+		"redirect_uri":  {client.RedirectURIs[0]},
+		"response_type": {"code"},
+		"scope":         {"openid email"},
+		"state":         {"xyz"},
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/authorize?"+q.Encode(), nil))
+	loc, _ := url.Parse(rec.Header().Get("Location"))
+	if loc == nil || loc.Query().Get("error") != "invalid_scope" || loc.Query().Get("state") != "xyz" {
+		t.Fatalf("authorize: got %d %q, want invalid_scope redirect", rec.Code, rec.Header().Get("Location"))
+	}
+}
+
+func TestGrantedScopeIsIntersection(t *testing.T) {
+	s, mux := newTestServer(t)
+	client := s.clients["client1"]
+	code := authorizeCode(t, s, mux, url.Values{"scope": {"openid profile email"}})
+
+	// 授权后客户端的允许范围被收紧，令牌只包含交集
+	client.AllowedScopes = []string{"openid", "email"}
+	status, body := postToken(t, mux, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {client.RedirectURIs[0]},
+		"client_id":     {client.ID},
+		"client_secret": {client.Secret},
+	})
+	if status != http.StatusOK || body["scope"] != "openid email" {
+		t.Fatalf("token: got %d %v, want scope \"openid email\"", status, body)
+	}
+}
+
+func TestConsentPageListsScopes(t *testing.T) {
+	s, mux := newTestServer(t)
+	client := s.clients["client1"]
+	s.sessions["session1"] = "user1"
+	q := url.Values{
+		"client_id":     {client.ID},
+		"redirect_uri":  {client.RedirectURIs[0]},
+		"response_type": {"code"},
+		"scope":         {"openid custom:read"},
+	}
+	req := httptest.NewRequest("GET", "/authorize?"+q.Encode(), nil)
+	req.AddCookie(&http.Cookie{Name: "oauth_session", Value: "session1"})
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	req = httptest.NewRequest("GET", rec.Header().Get("Location"), nil)
+	req.AddCookie(&http.Cookie{Name: "oauth_session", Value: "session1"})
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	page := rec.Body.String()
+	for _, want := range []string{"<code>openid</code>", "<code>custom:read</code>"} {
+		if !strings.Contains(page, want) {
+			t.Errorf("consent page missing %s", want)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package oauth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// signingKey 签发与校验JWT的密钥，RS256时公钥通过/jwks.json公开
+type signingKey struct {
+	method  jwt.SigningMethod
+	private interface{} // *rsa.PrivateKey 或 HMAC密钥
+	public  interface{} // *rsa.PublicKey 或 HMAC密钥
+	kid     string
+}
+
+// newSigningKey 按算法创建密钥，RS256时生成新的2048位RSA密钥，HS256时使用secret
+func newSigningKey(alg string, secret []byte) (*signingKey, error) {
+	switch alg {
+	case "", "RS256":
+		private, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return nil, fmt.Errorf("generate rsa key: %w", err)
+		}
+		k := &signingKey{method: jwt.SigningMethodRS256, private: private, public: &private.PublicKey}
+		k.kid = jwkThumbprint(&private.PublicKey)
+		return k, nil
+	case "HS256":
+		return hmacKey(secret), nil
+	}
+	return nil, fmt.Errorf("unsupported signing algorithm: %s", alg)
+}
+
+func hmacKey(secret []byte) *signingKey {
+	return &signingKey{method: jwt.SigningMethodHS256, private: secret, public: secret}
+}
+
+// issue 签发JWT，签发时间为当前时间，过期时间为当前时间加ttl
+func (k *signingKey) issue(claims JwtCustomClaims, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims.IssuedAt = jwt.NewNumericDate(now)
+	claims.ExpiresAt = jwt.NewNumericDate(now.Add(ttl))
+	return k.sign(&claims)
+}
+
+// sign 签名任意声明，RS256时在头部设置kid
+func (k *signingKey) sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(k.method, claims)
+	if k.kid != "" {
+		token.Header["kid"] = k.kid
+	}
+	return token.SignedString(k.private)
+}
+
+// verify 校验签名与有效期，只接受本密钥的算法
+func (k *signingKey) verify(tokenString string) (*JwtCustomClaims, error) {
+	claims := &JwtCustomClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return k.public, nil
+	}, jwt.WithValidMethods([]string{k.method.Alg()}))
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, fmt.Errorf("invalid token")
+	}
+	return claims, nil
+}
+
+// jwk 返回公钥的JWK表示，HS256的密钥不公开
+func (k *signingKey) jwk() (map[string]string, bool) {
+	public, ok := k.public.(*rsa.PublicKey)
+	if !ok {
+		return nil, false
+	}
+	return map[string]string{
+		"kty": "RSA",
+		"use": "sig",
+		"alg": k.method.Alg(),
+		"kid": k.kid,
+		"n":   base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+	}, true
+}
+
+// jwkThumbprint 按RFC 7638计算RSA公钥的指纹，用作kid
+func jwkThumbprint(public *rsa.PublicKey) string {
+	// 成员按字典序排列且无空白
+	b, _ := json.Marshal(struct {
+		E   string `json:"e"`
+		Kty string `json:"kty"`
+		N   string `json:"n"`
+	}{
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+		Kty: "RSA",
+		N:   base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+	})
+	sum := sha256.Sum256(b)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// jwksHandler 以JWK Set格式公开验签公钥
+func (s *AuthServer) jwksHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	keys := []map[string]string{}
+	if jwk, ok := s.signingKey.jwk(); ok {
+		keys = append(keys, jwk)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"keys": keys})
+}
+
+// idTokenClaims OIDC id_token的声明
+type idTokenClaims struct {
+	Nonce string `json:"nonce,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// idTokenTTL id_token有效期，与访问令牌一致
+const idTokenTTL = time.Hour
+
+// mintIDToken scope包含openid时为访问令牌签发id_token，aud为客户端ID
+func (s *AuthServer) mintIDToken(token *AccessToken, nonce string) error {
+	if !scopeCovers(token.Scope, "openid") {
+		return nil
+	}
+	now := time.Now()
+	idToken, err := s.signingKey.sign(&idTokenClaims{
+		Nonce: nonce,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    s.issuer,
+			Subject:   token.UserID,
+			Audience:  jwt.ClaimStrings{token.ClientID},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(idTokenTTL)),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	token.IDToken = idToken
+	return nil
+}
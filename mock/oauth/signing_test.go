@@ -0,0 +1,97 @@
+package oauth
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestRS256TokenVerifiesWithJWK(t *testing.T) {
+	key, err := newSigningKey("RS256", nil)
+	if err != nil {
+		t.Fatalf("newSigningKey: %v", err)
+	}
+	token, err := key.issue(JwtCustomClaims{UserID: "user1"}, time.Minute)
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+
+	// 只用JWKS中公开的信息还原公钥并验签
+	jwk, ok := key.jwk()
+	if !ok {
+		t.Fatal("RS256 key has no JWK")
+	}
+	n, _ := base64.RawURLEncoding.DecodeString(jwk["n"])
+	e, _ := base64.RawURLEncoding.DecodeString(jwk["e"])
+	public := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+
+	parsed, err := jwt.Parse(token, func(tk *jwt.Token) (interface{}, error) {
+		if tk.Header["kid"] != jwk["kid"] {
+			t.Errorf("kid = %v, want %s", tk.Header["kid"], jwk["kid"])
+		}
+		return public, nil
+	}, jwt.WithValidMethods([]string{"RS256"}))
+	if err != nil || !parsed.Valid {
+		t.Fatalf("token does not verify with the published JWK: %v", err)
+	}
+
+	// HS256密钥不应出现在JWKS中
+	if _, ok := hmacKey([]byte("secret")).jwk(); ok {
+		t.Error("HS256 secret exposed as a JWK")
+	}
+}
+
+func TestIDTokenForOpenIDScope(t *testing.T) {
+	s, mux := newTestServer(t)
+	client := s.clients["client1"]
+	code := authorizeCode(t, s, mux, url.Values{"nonce": {"n-0S6_WzA2Mj"}})
+	status, body := postToken(t, mux, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {client.RedirectURIs[0]},
+		"client_id":     {client.ID},
+		"client_secret": {client.Secret},
+	})
+	idToken, _ := body["id_token"].(string)
+	if status != http.StatusOK || idToken == "" {
+		t.Fatalf("token: got %d %v, want an id_token", status, body)
+	}
+
+	claims := &idTokenClaims{}
+	if _, err := jwt.ParseWithClaims(idToken, claims, func(*jwt.Token) (interface{}, error) {
+		return s.signingKey.public, nil
+	}); err != nil {
+		t.Fatalf("id_token does not verify: %v", err)
+	}
+	if claims.Subject != "user1" || claims.Issuer != s.issuer || claims.Nonce != "n-0S6_WzA2Mj" ||
+		len(claims.Audience) != 1 || claims.Audience[0] != client.ID {
+		t.Errorf("unexpected id_token claims: %+v", claims)
+	}
+}
+
+func TestConfiguredIssuerAndSecret(t *testing.T) {
+	s, err := NewAuthServer(Config{Issuer: "https://auth.example.com/", SigningAlg: "HS256", JwtSecret: "s3cret"})
+	if err != nil {
+		t.Fatalf("NewAuthServer: %v", err)
+	}
+	token, err := s.mintAccessToken("user1", "client1", "openid")
+	if err != nil {
+		t.Fatalf("mintAccessToken: %v", err)
+	}
+	claims, err := VerifyToken(token.Token, []byte("s3cret"))
+	if err != nil {
+		t.Fatalf("token does not verify with the configured secret: %v", err)
+	}
+	if claims.Issuer != "https://auth.example.com" {
+		t.Errorf("iss = %q, want https://auth.example.com", claims.Issuer)
+	}
+	if _, err := VerifyToken(token.Token, []byte(defaultJwtSecret)); err == nil {
+		t.Error("token verifies with the default secret")
+	}
+}
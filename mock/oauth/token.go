@@ -0,0 +1,13 @@
+package oauth
+
+import "time"
+
+// IssueToken 使用HS256签发JWT，签发时间为当前时间，过期时间为当前时间加ttl
+func IssueToken(claims JwtCustomClaims, key []byte, ttl time.Duration) (string, error) {
+	return hmacKey(key).issue(claims, ttl)
+}
+
+// VerifyToken 校验IssueToken签发的JWT的签名与有效期，返回其中的声明
+func VerifyToken(tokenString string, key []byte) (*JwtCustomClaims, error) {
+	return hmacKey(key).verify(tokenString)
+}
@@ -0,0 +1,45 @@
+package oauth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIssueAndVerifyToken(t *testing.T) {
+	key := []byte("test-secret")
+	claims := JwtCustomClaims{UserID: "user1", ClientID: "client1", Scope: "openid email"}
+
+	token, err := IssueToken(claims, key, time.Minute)
+	if err != nil {
+		t.Fatalf("IssueToken: %v", err)
+	}
+
+	got, err := VerifyToken(token, key)
+	if err != nil {
+		t.Fatalf("VerifyToken: %v", err)
+	}
+	if got.UserID != "user1" || got.ClientID != "client1" || got.Scope != "openid email" {
+		t.Errorf("unexpected claims: %+v", got)
+	}
+	if got.ExpiresAt == nil || got.ExpiresAt.Sub(got.IssuedAt.Time) != time.Minute {
+		t.Errorf("expected 1m lifetime, got iat=%v exp=%v", got.IssuedAt, got.ExpiresAt)
+	}
+}
+
+func TestVerifyTokenRejectsWrongKeyAndExpired(t *testing.T) {
+	token, err := IssueToken(JwtCustomClaims{UserID: "user1"}, []byte("key-a"), time.Minute)
+	if err != nil {
+		t.Fatalf("IssueToken: %v", err)
+	}
+	if _, err := VerifyToken(token, []byte("key-b")); err == nil {
+		t.Error("expected signature error for wrong key")
+	}
+
+	expired, err := IssueToken(JwtCustomClaims{UserID: "user1"}, []byte("key-a"), -time.Minute)
+	if err != nil {
+		t.Fatalf("IssueToken: %v", err)
+	}
+	if _, err := VerifyToken(expired, []byte("key-a")); err == nil {
+		t.Error("expected error for expired token")
+	}
+}
@@ -0,0 +1,38 @@
+package oauth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestUserInfoRejectsExpiredToken(t *testing.T) {
+	s, mux := newTestServer(t)
+	token, err := s.mintAccessToken("user1", "client1", "openid email")
+	if err != nil {
+		t.Fatalf("mintAccessToken: %v", err)
+	}
+	userinfo := func() (int, map[string]interface{}) {
+		req := httptest.NewRequest("GET", "/userinfo", nil)
+		req.Header.Set("Authorization", "Bearer "+token.Token)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		body := map[string]interface{}{}
+		json.Unmarshal(rec.Body.Bytes(), &body)
+		return rec.Code, body
+	}
+
+	if status, body := userinfo(); status != http.StatusOK || body["email"] != "alice@example.com" {
+		t.Fatalf("valid token: got %d %v", status, body)
+	}
+
+	token.ExpiresAt = time.Now().Add(-time.Second)
+	if status, body := userinfo(); status != http.StatusUnauthorized || body["error"] != "invalid_token" {
+		t.Errorf("expired token: got %d %v, want 401 invalid_token", status, body)
+	}
+	if _, exists := s.accessTokens[token.Token]; exists {
+		t.Error("expired token was not evicted")
+	}
+}
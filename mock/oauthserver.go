@@ -2,15 +2,36 @@ package mock
 
 import (
 	"fmt"
-	"log"
 	"net/http"
 
 	"github.com/yusiwen/myUtilities/mock/oauth"
 )
 
 func (o OAuthServerOptions) Run() error {
+	cfg := oauth.Config{
+		AdminSecret: o.AdminSecret,
+		Issuer:      o.Issuer,
+		SigningAlg:  o.SigningAlg,
+		JwtSecret:   o.JwtSecret,
+	}
+	// 未指定issuer时使用本机监听地址
+	if cfg.Issuer == "" {
+		cfg.Issuer = fmt.Sprintf("http://localhost:%d", o.Port)
+	}
+	// 从配置文件加载客户端与用户
+	if o.Config != "" {
+		clients, users, err := oauth.LoadConfigFile(o.Config)
+		if err != nil {
+			return err
+		}
+		cfg.Clients, cfg.Users = clients, users
+	}
+
 	// 创建认证服务器实例
-	authServer := oauth.NewAuthServer()
+	authServer, err := oauth.NewAuthServer(cfg)
+	if err != nil {
+		return fmt.Errorf("create oauth server failed: %v", err)
+	}
 
 	// 创建HTTP多路复用器
 	mux := http.NewServeMux()
@@ -20,6 +41,8 @@ func (o OAuthServerOptions) Run() error {
 
 	// 启动服务器
 	fmt.Println(fmt.Sprintf("OAuth server started on http://localhost:%d", o.Port))
-	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", o.Port), mux))
+	if err := http.ListenAndServe(fmt.Sprintf(":%d", o.Port), mux); err != nil {
+		return fmt.Errorf("server listen failed: %v", err)
+	}
 	return nil
 }
@@ -1,20 +1,65 @@
 package mock
 
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
 type FileServerOptions struct {
-	LocalDir    string `help:"Local directory to serve." default:"./tmp/uploads"`
-	Port        int    `help:"Port to listen on." default:"8082"`
-	FormKey     string `help:"File upload request form key name." default:"files"`
-	MaxFileSize int64  `help:"Maximum file size in megabytes." default:"50"`
+	LocalDir      string        `help:"Local directory to serve." default:"./tmp/uploads"`
+	Port          int           `help:"Port to listen on." default:"8082"`
+	FormKey       string        `help:"File upload request form key name." default:"files"`
+	MaxFileSize   int64         `help:"Maximum file size in megabytes." default:"50"`
+	Metrics       bool          `help:"Expose Prometheus-style metrics at /metrics."`
+	MetricsPort   int           `help:"Serve /metrics on a separate port instead of the main one."`
+	UploadDelay   time.Duration `help:"Delay before responding to a successful upload." default:"0s"`
+	DelayJitter   time.Duration `help:"Random extra delay, up to this value, added to --upload-delay." default:"0s"`
+	Throttle      int64         `help:"Limit how fast uploads are stored, in bytes per second (0 = unlimited)."`
+	BasePath      string        `help:"Base path of the upload endpoints." default:"/api/mock"`
+	Token         string        `help:"Require Authorization: Bearer <token> on the upload, list and delete endpoints; empty leaves them open." env:"FILE_SERVER_TOKEN"`
+	TlsCert       string        `help:"Serve HTTPS with this PEM certificate file (requires --tls-key)."`
+	TlsKey        string        `help:"PEM private key file for --tls-cert."`
+	TlsSelfSigned bool          `help:"Serve HTTPS with a self-signed certificate for localhost generated at startup."`
+	AllowPurge    bool          `help:"Allow DELETE on the files endpoint to remove everything under --local-dir."`
+	PreservePaths bool          `help:"Keep the directory part of uploaded file names (e.g. images/logo.png) under --local-dir instead of flattening them; names containing .. are rejected."`
+	LogJson       bool          `help:"Write file server logs as JSON lines instead of text."`
+	Methods       []string      `help:"HTTP methods accepted for uploads." default:"POST"`
+	AllowedTypes  []string      `help:"Only accept uploads matching these extensions (.png) or content types (image/png, image/*) detected from the first 512 bytes; empty accepts everything."`
+
+	log     *slog.Logger `kong:"-"`
+	metrics *httpMetrics `kong:"-"` // 启用--metrics时创建，Handler中的处理函数共用
 }
 
 type MockServerOptions struct {
-	Port     int    `help:"Port to listen on." default:"8081"`
-	Size     int    `help:"Number of records to generate." default:"100"`
-	CsvFiles string `help:"CSV files to read as data, separated by semi-colon" default:""`
+	Port          int           `help:"Port to listen on." default:"8081"`
+	Size          int           `help:"Number of records to generate." default:"100"`
+	CsvFiles      string        `help:"Data files to serve, separated by semi-colon: .csv, .json (array of objects) or .ndjson/.jsonl (one object per line)." default:""`
+	NoInfer       bool          `help:"Keep CSV values as strings instead of inferring int, float, bool and null types."`
+	BadRows       string        `help:"How to handle malformed CSV rows or JSON records (and invalid records with --validate): 'error' or 'skip'." enum:"error,skip" default:"error"`
+	Schema        string        `help:"JSON Schema file describing a record, used for random data and --validate (defaults to the built-in id/name schema)."`
+	Validate      bool          `help:"Validate every loaded or generated record against --schema before serving; --bad-rows decides whether invalid records fail the load or are skipped."`
+	Metrics       bool          `help:"Expose Prometheus-style metrics at /metrics."`
+	MetricsPort   int           `help:"Serve /metrics on a separate port instead of the main one."`
+	Latency       string        `help:"Delay every query response by a fixed duration (e.g. 200ms) or a random one in a range (e.g. 100ms-500ms)."`
+	ErrorRate     float64       `help:"Probability (0.0-1.0) that a query fails with an HTTP 500 or a Code 9 error envelope." default:"0"`
+	CorsOrigin    string        `help:"Allowed CORS origin(s), comma-separated; * allows any origin and an empty value disables CORS headers." default:"*"`
+	Watch         bool          `help:"Reload a data file's dataset when the file changes."`
+	WatchInterval time.Duration `help:"How often --watch checks the data files for changes." default:"1s"`
+
+	latency latencyRange `kong:"-"`
+	metrics *httpMetrics `kong:"-"`
+	// 停止当前的数据文件监控，由startWatch设置
+	stopWatch context.CancelFunc `kong:"-"`
 }
 
 type OAuthServerOptions struct {
-	Port int `help:"Port to listen on." default:"8083"`
+	Port        int    `help:"Port to listen on." default:"8083"`
+	AdminSecret string `help:"Secret guarding the admin endpoints (e.g. /admin/token); admin endpoints are disabled when empty." env:"OAUTH_ADMIN_SECRET"`
+	SigningAlg  string `help:"JWT signing algorithm: RS256 (key generated at startup, published at /jwks.json) or HS256 (shared secret)." enum:"RS256,HS256" default:"RS256"`
+	Issuer      string `help:"Issuer URL used as the JWT iss and the base of the discovery document endpoints (defaults to http://localhost:<port>)."`
+	JwtSecret   string `help:"Shared secret for HS256 signing." env:"OAUTH_JWT_SECRET" default:"your-256-bit-secret"`
+	Config      string `help:"JSON or YAML file with the clients and users to load at startup (the demo client1/alice are used when omitted)." type:"existingfile"`
 }
 
 type DynamicServerOptions struct {
@@ -22,9 +67,17 @@ type DynamicServerOptions struct {
 	Verbose bool   `help:"Print request and response details."`
 }
 
+type GenOptions struct {
+	Schema string `help:"JSON Schema file describing a record (defaults to the built-in id/name schema)."`
+	Count  int    `help:"Number of records to generate." default:"100"`
+	Output string `help:"Output file, '-' for stdout." short:"o" default:"-"`
+	Format string `help:"Output format, 'json' or 'csv'; inferred from the output file extension when omitted." enum:",json,csv" default:""`
+}
+
 type Options struct {
 	FileServer    FileServerOptions    `cmd:"" name:"file-server" help:"Start a mock file server to receive files."`
 	MockServer    MockServerOptions    `cmd:"" name:"mock-server" help:"Start a mock server to receive requests."`
 	OAuthServer   OAuthServerOptions   `cmd:"" name:"oauth-server" help:"Start a mock oauth server to receive requests."`
 	DynamicServer DynamicServerOptions `cmd:"" name:"dynamic-server" help:"Start a dynamic mock server with configurable method, path and response."`
+	Gen           GenOptions           `cmd:"" name:"gen" help:"Generate fixture records from a JSON Schema into a CSV or JSON file."`
 }
@@ -0,0 +1,148 @@
+package mock
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// sortSpec 单个排序字段，dir为asc（默认）或desc
+type sortSpec struct {
+	Field string `json:"field"`
+	Dir   string `json:"dir"`
+}
+
+// sortRecords 按排序字段列表对记录做稳定排序，返回新切片，不修改共享数据
+func sortRecords(records []interface{}, specs []sortSpec) []interface{} {
+	if len(specs) == 0 {
+		return records
+	}
+	sorted := slices.Clone(records)
+	slices.SortStableFunc(sorted, func(a, b interface{}) int {
+		for _, spec := range specs {
+			c := compareValues(recordField(a, spec.Field), recordField(b, spec.Field))
+			if strings.EqualFold(spec.Dir, "desc") {
+				c = -c
+			}
+			if c != 0 {
+				return c
+			}
+		}
+		return 0
+	})
+	return sorted
+}
+
+// filterCond 单个字段的过滤条件，可以直接写值表示相等，
+// 或写成 {"eq": 值} / {"contains": "子串"}（不区分大小写）
+type filterCond struct {
+	Eq       interface{} `json:"eq"`
+	Contains *string     `json:"contains"`
+}
+
+func (c *filterCond) UnmarshalJSON(b []byte) error {
+	var obj map[string]json.RawMessage
+	if json.Unmarshal(b, &obj) == nil {
+		for k := range obj {
+			if k != "eq" && k != "contains" {
+				return fmt.Errorf("unknown filter operator %q", k)
+			}
+		}
+		type plain filterCond
+		return json.Unmarshal(b, (*plain)(c))
+	}
+	return json.Unmarshal(b, &c.Eq)
+}
+
+// matches 判断字段值是否满足条件，相等比较兼容数值与其字符串形式（如 1 与 "1"）
+func (c filterCond) matches(v interface{}) bool {
+	if c.Contains != nil {
+		return v != nil && strings.Contains(strings.ToLower(fmt.Sprint(v)), strings.ToLower(*c.Contains))
+	}
+	if v == nil || c.Eq == nil {
+		return v == nil && c.Eq == nil
+	}
+	return compareValues(v, c.Eq) == 0 || fmt.Sprint(v) == fmt.Sprint(c.Eq)
+}
+
+// filterRecords 保留满足全部字段条件的记录，返回新切片，不修改共享数据
+func filterRecords(records []interface{}, filter map[string]filterCond) []interface{} {
+	if len(filter) == 0 {
+		return records
+	}
+	matched := []interface{}{}
+	for _, record := range records {
+		ok := true
+		for field, cond := range filter {
+			if !cond.matches(recordField(record, field)) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			matched = append(matched, record)
+		}
+	}
+	return matched
+}
+
+// recordField 读取记录中的字段，非对象记录返回nil
+func recordField(record interface{}, field string) interface{} {
+	if m, ok := record.(map[string]interface{}); ok {
+		return m[field]
+	}
+	return nil
+}
+
+// compareValues 数值按大小比较，布尔值false在前，nil排在最前，其他按字符串比较
+func compareValues(a, b interface{}) int {
+	if a == nil || b == nil {
+		switch {
+		case a == nil && b == nil:
+			return 0
+		case a == nil:
+			return -1
+		default:
+			return 1
+		}
+	}
+	if fa, ok := toFloat(a); ok {
+		if fb, ok := toFloat(b); ok {
+			return cmp.Compare(fa, fb)
+		}
+	}
+	if ba, ok := a.(bool); ok {
+		if bb, ok := b.(bool); ok {
+			switch {
+			case ba == bb:
+				return 0
+			case !ba:
+				return -1
+			default:
+				return 1
+			}
+		}
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
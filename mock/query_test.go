@@ -0,0 +1,179 @@
+package mock
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testRecords() []interface{} {
+	return []interface{}{
+		map[string]interface{}{"id": int64(1), "name": "Carol", "age": 30.0, "admin": true},
+		map[string]interface{}{"id": int64(2), "name": "alice", "age": 25.0, "admin": false},
+		map[string]interface{}{"id": int64(3), "name": "Bob", "age": nil, "admin": false},
+		map[string]interface{}{"id": int64(4), "name": "Alan", "age": 25.0, "admin": true},
+	}
+}
+
+// ids 返回记录的id列表
+func ids(records []interface{}) []int64 {
+	out := make([]int64, len(records))
+	for i, r := range records {
+		switch id := recordField(r, "id").(type) {
+		case int64:
+			out[i] = id
+		case float64:
+			out[i] = int64(id)
+		}
+	}
+	return out
+}
+
+func TestCompareValues(t *testing.T) {
+	cases := []struct {
+		a, b interface{}
+		want int
+	}{
+		{int64(2), 10.0, -1},
+		{json.Number("3.5"), 3, 1},
+		{nil, "a", -1},
+		{"a", nil, 1},
+		{nil, nil, 0},
+		{false, true, -1},
+		{true, true, 0},
+		{"b", "a", 1},
+		{"10", "9", -1}, // 字符串按字典序
+	}
+	for _, c := range cases {
+		if got := compareValues(c.a, c.b); got != c.want {
+			t.Errorf("compareValues(%v, %v) = %d, want %d", c.a, c.b, got, c.want)
+		}
+	}
+}
+
+func TestSortRecords(t *testing.T) {
+	cases := []struct {
+		name  string
+		specs []sortSpec
+		want  []int64
+	}{
+		{"none", nil, []int64{1, 2, 3, 4}},
+		{"asc nil first", []sortSpec{{Field: "age"}}, []int64{3, 2, 4, 1}},
+		{"desc", []sortSpec{{Field: "age", Dir: "DESC"}}, []int64{1, 2, 4, 3}},
+		{"tie broken by second field", []sortSpec{{Field: "age"}, {Field: "name"}}, []int64{3, 4, 2, 1}},
+		{"bool", []sortSpec{{Field: "admin", Dir: "desc"}}, []int64{1, 4, 2, 3}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			records := testRecords()
+			got := sortRecords(records, c.specs)
+			if !reflect.DeepEqual(ids(got), c.want) {
+				t.Errorf("got %v, want %v", ids(got), c.want)
+			}
+			if !reflect.DeepEqual(ids(records), []int64{1, 2, 3, 4}) {
+				t.Errorf("input was modified: %v", ids(records))
+			}
+		})
+	}
+}
+
+func TestFilterRecords(t *testing.T) {
+	cases := []struct {
+		name   string
+		filter string
+		want   []int64
+	}{
+		{"eq number", `{"age": 25}`, []int64{2, 4}},
+		{"eq string form of number", `{"id": "3"}`, []int64{3}},
+		{"eq null", `{"age": null}`, []int64{3}},
+		{"eq operator", `{"admin": {"eq": true}}`, []int64{1, 4}},
+		{"contains ignores case", `{"name": {"contains": "AL"}}`, []int64{2, 4}},
+		{"all conditions", `{"age": 25, "admin": false}`, []int64{2}},
+		{"no match", `{"name": "nobody"}`, []int64{}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var filter map[string]filterCond
+			if err := json.Unmarshal([]byte(c.filter), &filter); err != nil {
+				t.Fatalf("unmarshal filter: %v", err)
+			}
+			if got := ids(filterRecords(testRecords(), filter)); !reflect.DeepEqual(got, c.want) {
+				t.Errorf("got %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestFilterRejectsUnknownOperator(t *testing.T) {
+	var filter map[string]filterCond
+	if err := json.Unmarshal([]byte(`{"age": {"gt": 1}}`), &filter); err == nil {
+		t.Error("expected an error for an unknown operator")
+	}
+}
+
+// queryPage 通过路由调用queryHandler，返回解析后的响应
+func queryPage(t *testing.T, req *http.Request) MockResponse {
+	t.Helper()
+	o := &MockServerOptions{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/mock/query/{rs}", o.queryHandler)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	var resp MockResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid response: %v\n%s", err, rec.Body.String())
+	}
+	return resp
+}
+
+func TestQueryPaging(t *testing.T) {
+	records := make([]interface{}, 7)
+	for i := range records {
+		records[i] = map[string]interface{}{"id": int64(i + 1)}
+	}
+	data.set("paging", records)
+
+	cases := []struct {
+		name                                string
+		req                                 *http.Request
+		wantIDs                             []int64
+		wantPageNo, wantPageSize, wantPages int
+	}{
+		{"GET first page", httptest.NewRequest(http.MethodGet, "/api/mock/query/paging?pageNo=1&pageSize=3", nil), []int64{1, 2, 3}, 1, 3, 3},
+		{"GET last partial page", httptest.NewRequest(http.MethodGet, "/api/mock/query/paging?pageNo=3&pageSize=3", nil), []int64{7}, 3, 3, 3},
+		{"GET past the end", httptest.NewRequest(http.MethodGet, "/api/mock/query/paging?pageNo=4&pageSize=3", nil), []int64{}, 4, 3, 3},
+		{"GET without paging returns all", httptest.NewRequest(http.MethodGet, "/api/mock/query/paging", nil), []int64{1, 2, 3, 4, 5, 6, 7}, 1, 7, 1},
+		{"POST with sort and filter", httptest.NewRequest(http.MethodPost, "/api/mock/query/paging",
+			strings.NewReader(`{"pageNo": 1, "pageSize": 2, "sort": [{"field": "id", "dir": "desc"}]}`)), []int64{7, 6}, 1, 2, 4},
+		{"POST page 0 is page 1", httptest.NewRequest(http.MethodPost, "/api/mock/query/paging",
+			strings.NewReader(`{"pageNo": 0, "pageSize": 5}`)), []int64{1, 2, 3, 4, 5}, 1, 5, 2},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			resp := queryPage(t, c.req)
+			if resp.Status.Code != "0" {
+				t.Fatalf("status %+v", resp.Status)
+			}
+			got := resp.Result
+			data, _ := got.Data.([]interface{})
+			if !reflect.DeepEqual(ids(data), c.wantIDs) {
+				t.Errorf("ids = %v, want %v", ids(data), c.wantIDs)
+			}
+			if got.Total != 7 || got.PageNo != c.wantPageNo || got.PageSize != c.wantPageSize || got.TotalPages != c.wantPages {
+				t.Errorf("paging = total %d, page %d, size %d, pages %d; want 7, %d, %d, %d",
+					got.Total, got.PageNo, got.PageSize, got.TotalPages, c.wantPageNo, c.wantPageSize, c.wantPages)
+			}
+		})
+	}
+}
+
+func TestQueryRejectsBadPaging(t *testing.T) {
+	resp := queryPage(t, httptest.NewRequest(http.MethodGet, "/api/mock/query/paging?pageNo=x", nil))
+	if resp.Status.Code != "2" {
+		t.Errorf("status code = %q, want 2", resp.Status.Code)
+	}
+}
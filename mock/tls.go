@@ -0,0 +1,64 @@
+package mock
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"fmt"
+	"strings"
+
+	corecrypto "github.com/yusiwen/myUtilities/core/crypto"
+)
+
+// tlsConfig 根据--tls-cert/--tls-key或--tls-self-signed构造TLS配置，均未配置时返回nil表示使用HTTP；
+// 返回的指纹为证书DER的SHA-256，供客户端固定证书
+func (o FileServerOptions) tlsConfig() (*tls.Config, string, error) {
+	if (o.TlsCert == "") != (o.TlsKey == "") {
+		return nil, "", fmt.Errorf("--tls-cert and --tls-key must be given together")
+	}
+	if o.TlsSelfSigned && o.TlsCert != "" {
+		return nil, "", fmt.Errorf("--tls-self-signed cannot be combined with --tls-cert/--tls-key")
+	}
+
+	var cert tls.Certificate
+	var err error
+	switch {
+	case o.TlsCert != "":
+		if cert, err = tls.LoadX509KeyPair(o.TlsCert, o.TlsKey); err != nil {
+			return nil, "", fmt.Errorf("load TLS certificate failed: %v", err)
+		}
+	case o.TlsSelfSigned:
+		if cert, err = selfSignedCert(); err != nil {
+			return nil, "", fmt.Errorf("generate self-signed certificate failed: %v", err)
+		}
+	default:
+		return nil, "", nil
+	}
+	return &tls.Config{Certificates: []tls.Certificate{cert}}, certFingerprint(cert), nil
+}
+
+// selfSignedCert 在内存中生成localhost的自签名证书，只在本次运行中有效
+func selfSignedCert() (tls.Certificate, error) {
+	certPEM, keyPEM, err := (&corecrypto.RSACipher{}).GenerateSelfSignedCert(corecrypto.CertParams{
+		CommonName: "localhost",
+		SANs:       []string{"localhost", "127.0.0.1", "::1"},
+		Bits:       2048,
+		ValidDays:  1,
+	})
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.X509KeyPair(certPEM, keyPEM)
+}
+
+// certFingerprint 返回证书链首个证书的SHA-256指纹，格式为冒号分隔的大写十六进制
+func certFingerprint(cert tls.Certificate) string {
+	if len(cert.Certificate) == 0 {
+		return ""
+	}
+	sum := sha256.Sum256(cert.Certificate[0])
+	parts := make([]string, len(sum))
+	for i, b := range sum {
+		parts[i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(parts, ":")
+}
@@ -0,0 +1,97 @@
+package mock
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ryanolee/go-chaff"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/santhosh-tekuri/jsonschema/v6/kind"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// parseJSONSchema 编译记录校验用的JSON Schema，默认按draft 2020-12处理（可用$schema指定其他版本），
+// 支持$ref、oneOf/anyOf/allOf、const、format等全部关键字，format会实际校验
+func parseJSONSchema(schemaJSON string) (*jsonschema.Schema, error) {
+	doc, err := jsonschema.UnmarshalJSON(strings.NewReader(schemaJSON))
+	if err != nil {
+		return nil, fmt.Errorf("parse schema: %w", err)
+	}
+	c := jsonschema.NewCompiler()
+	c.AssertFormat()
+	if err := c.AddResource(schemaURL, doc); err != nil {
+		return nil, fmt.Errorf("parse schema: %w", err)
+	}
+	s, err := c.Compile(schemaURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	return s, nil
+}
+
+// schemaURL 模式在编译器中的位置，只用于错误信息和文档内的$ref
+const schemaURL = "schema.json"
+
+// checkSchema 在启动时校验JSON Schema：须符合JSON Schema元模式，并且能被随机数据生成器解析
+func checkSchema(schemaJSON string) error {
+	if _, err := parseJSONSchema(schemaJSON); err != nil {
+		return err
+	}
+	if _, err := chaff.ParseSchemaStringWithDefaults(schemaJSON); err != nil {
+		return fmt.Errorf("generator: %w", err)
+	}
+	return nil
+}
+
+// readSchema 读取JSON Schema文件，path为空时返回内置的id/name模式
+func readSchema(path string) (string, error) {
+	if path == "" {
+		return schema, nil
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("read schema: %w", err)
+	}
+	return string(b), nil
+}
+
+// printer 输出校验错误信息
+var printer = message.NewPrinter(language.English)
+
+// validateRecord 校验记录是否符合模式，返回第一个不符合项，如 "at '/id': got string, want integer"
+func validateRecord(s *jsonschema.Schema, record interface{}) error {
+	err := s.Validate(record)
+	var ve *jsonschema.ValidationError
+	if !errors.As(err, &ve) {
+		return err
+	}
+	// 跳过只表示"某个子模式失败"的外层错误，定位到具体的关键字
+	for len(ve.Causes) > 0 {
+		switch ve.ErrorKind.(type) {
+		case *kind.Schema, *kind.Group, *kind.Reference, *kind.AllOf:
+			ve = ve.Causes[0]
+			continue
+		}
+		break
+	}
+	return fmt.Errorf("at '/%s': %s", strings.Join(ve.InstanceLocation, "/"), ve.ErrorKind.LocalizedString(printer))
+}
+
+// validateRecords 按模式校验数据集中的记录，skip为true时丢弃并打印不符合的记录，否则返回第一个错误
+func validateRecords(s *jsonschema.Schema, name string, records []interface{}, skip bool) ([]interface{}, error) {
+	valid := records[:0:0]
+	for i, r := range records {
+		if err := validateRecord(s, r); err != nil {
+			if !skip {
+				return nil, fmt.Errorf("%s record %d: %w", name, i+1, err)
+			}
+			fmt.Printf("skipping invalid %s record %d: %v\n", name, i+1, err)
+			continue
+		}
+		valid = append(valid, r)
+	}
+	return valid, nil
+}
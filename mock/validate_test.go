@@ -0,0 +1,103 @@
+package mock
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testSchema = `{
+	"$defs": {"id": {"type": "integer", "minimum": 1}},
+	"type": "object",
+	"properties": {
+		"id": {"$ref": "#/$defs/id"},
+		"name": {"type": "string", "minLength": 1},
+		"email": {"type": "string", "format": "email"},
+		"kind": {"const": "user"},
+		"tag": {"oneOf": [{"type": "string"}, {"type": "null"}]},
+		"meta": {"type": "object", "additionalProperties": {"type": "string"}}
+	},
+	"required": ["id", "name"],
+	"additionalProperties": false
+}`
+
+func TestValidateRecord(t *testing.T) {
+	s, err := parseJSONSchema(testSchema)
+	if err != nil {
+		t.Fatalf("parseJSONSchema: %v", err)
+	}
+
+	cases := []struct {
+		name    string
+		record  map[string]interface{}
+		wantErr string // 为空表示应通过校验
+	}{
+		{"valid", map[string]interface{}{"id": 1.0, "name": "a", "email": "a@b.c", "kind": "user", "tag": nil, "meta": map[string]interface{}{"k": "v"}}, ""},
+		{"csv int64", map[string]interface{}{"id": int64(2), "name": "b"}, ""},
+		{"missing required", map[string]interface{}{"id": 1.0}, "name"},
+		{"wrong type", map[string]interface{}{"id": "1", "name": "a"}, "/id"},
+		{"$ref minimum", map[string]interface{}{"id": 0.0, "name": "a"}, "/id"},
+		{"format", map[string]interface{}{"id": 1.0, "name": "a", "email": "nope"}, "/email"},
+		{"const", map[string]interface{}{"id": 1.0, "name": "a", "kind": "admin"}, "/kind"},
+		{"oneOf", map[string]interface{}{"id": 1.0, "name": "a", "tag": 3.0}, "/tag"},
+		{"additionalProperties schema", map[string]interface{}{"id": 1.0, "name": "a", "meta": map[string]interface{}{"k": 1.0}}, "/meta/k"},
+		{"additionalProperties false", map[string]interface{}{"id": 1.0, "name": "a", "extra": true}, "extra"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := validateRecord(s, c.record)
+			switch {
+			case c.wantErr == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case c.wantErr != "" && err == nil:
+				t.Errorf("expected an error mentioning %q", c.wantErr)
+			case c.wantErr != "" && !strings.Contains(err.Error(), c.wantErr):
+				t.Errorf("error %q does not mention %q", err, c.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckSchemaRejectsInvalidSchema(t *testing.T) {
+	for _, bad := range []string{`{"type": "strin"}`, `{"minimum": "1"}`, `{"pattern": "("}`, `not json`} {
+		if err := checkSchema(bad); err == nil {
+			t.Errorf("checkSchema(%s): expected an error", bad)
+		}
+	}
+}
+
+// writeDataFile 在临时目录中写入数据文件，返回路径
+func writeDataFile(t *testing.T, name, content string) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestValidateFailFastAndSkip(t *testing.T) {
+	schemaFile := writeDataFile(t, "schema.json", testSchema)
+	csvFile := writeDataFile(t, "users.csv", "id,name\n1,a\n0,b\n3,c\n")
+
+	o := &MockServerOptions{CsvFiles: csvFile, Schema: schemaFile, Validate: true, BadRows: "error"}
+	err := o.generateData()
+	if err == nil || !strings.Contains(err.Error(), "users record 2") {
+		t.Fatalf("fail-fast: got %v, want an error for users record 2", err)
+	}
+
+	o.BadRows = "skip"
+	if err := o.generateData(); err != nil {
+		t.Fatalf("skip: %v", err)
+	}
+	records := data.get("users")
+	if len(records) != 2 {
+		t.Fatalf("skip: got %d records, want 2", len(records))
+	}
+	for _, r := range records {
+		if r.(map[string]interface{})["id"] == int64(0) {
+			t.Errorf("skip: invalid record was kept: %v", r)
+		}
+	}
+}
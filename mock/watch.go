@@ -0,0 +1,75 @@
+package mock
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/yusiwen/myUtilities/core/watcher"
+)
+
+// startWatch 停止上一次启动的监控，重新监控全部数据文件，监控在ctx结束时停止；
+// 数据文件被删除后监控随之结束，重新加载（SIGHUP）时由此重新开始
+func (o *MockServerOptions) startWatch(ctx context.Context) error {
+	if o.stopWatch != nil {
+		o.stopWatch()
+	}
+	watchCtx, cancel := context.WithCancel(ctx)
+	o.stopWatch = cancel
+	if err := o.watchFiles(watchCtx); err != nil {
+		cancel()
+		return err
+	}
+	return nil
+}
+
+// watchFiles 监控--csv-files中的每个数据文件，文件变化时只重新加载该文件对应的数据集
+func (o *MockServerOptions) watchFiles(ctx context.Context) error {
+	if o.CsvFiles == "" {
+		return fmt.Errorf("--watch requires --csv-files")
+	}
+	for _, file := range strings.Split(o.CsvFiles, ";") {
+		w := watcher.NewFileWatcher(file, o.WatchInterval)
+		events, err := w.Watch(ctx)
+		if err != nil {
+			return fmt.Errorf("watch %s: %w", file, err)
+		}
+		go func() {
+			for event := range events {
+				switch event.Type {
+				case watcher.Added, watcher.Modified:
+					if err := o.reloadFile(file); err != nil {
+						fmt.Printf("reload %s failed, keeping previous data: %v\n", file, err)
+						continue
+					}
+					fmt.Printf("reloaded %s\n", file)
+				case watcher.Error:
+					fmt.Printf("watch %s: %v\n", file, event.Object)
+				}
+			}
+		}()
+	}
+	fmt.Printf("watching %s for changes\n", o.CsvFiles)
+	return nil
+}
+
+// reloadFile 重新加载单个数据文件并替换对应的数据集，失败时保留原数据
+func (o *MockServerOptions) reloadFile(file string) error {
+	newData := make(map[string][]interface{})
+	if err := o.loadFile(file, newData); err != nil {
+		return err
+	}
+	if o.Validate {
+		schemaJSON, err := readSchema(o.Schema)
+		if err != nil {
+			return err
+		}
+		if err := o.validateData(schemaJSON, newData); err != nil {
+			return err
+		}
+	}
+	for name, records := range newData {
+		data.set(name, records)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package mock
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// 监控随ctx结束，结束后文件变化不再触发重新加载
+func TestStartWatchStopsWithContext(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "watched.json")
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	waitRecords := func(n int) bool {
+		for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+			if len(data.get("watched")) == n {
+				return true
+			}
+		}
+		return false
+	}
+	write(`[{"id": 1, "name": "a"}]`)
+
+	o := &MockServerOptions{CsvFiles: file, WatchInterval: 20 * time.Millisecond}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// 模拟SIGHUP重新加载时重新开始监控
+	for range 2 {
+		if err := o.startWatch(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(`[{"id": 1, "name": "a"}, {"id": 2, "name": "b"}]`)
+	if !waitRecords(2) {
+		t.Fatalf("dataset not reloaded: %v", data.get("watched"))
+	}
+
+	cancel()
+	time.Sleep(50 * time.Millisecond)
+	write(`[{"id": 1, "name": "a"}, {"id": 2, "name": "b"}, {"id": 3, "name": "c"}]`)
+	time.Sleep(200 * time.Millisecond)
+	if n := len(data.get("watched")); n != 2 {
+		t.Errorf("dataset reloaded after the watch stopped: %d records", n)
+	}
+}
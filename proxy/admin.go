@@ -0,0 +1,42 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/yusiwen/myUtilities/core/proxy/db"
+)
+
+// adminHandler 管理接口：/status 返回文本报告，/status.json 返回JSON报告
+func adminHandler(p *db.DBProxy) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /status", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprint(w, p.GetStatusReport())
+	})
+	mux.HandleFunc("GET /status.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(p.StatusReport())
+	})
+	return mux
+}
+
+// serveAdmin 在后台启动管理接口，ctx取消后关闭
+func serveAdmin(ctx context.Context, addr string, p *db.DBProxy) {
+	server := &http.Server{Addr: addr, Handler: adminHandler(p)}
+	go func() {
+		log.Printf("Admin endpoint listening at http://%s/status", addr)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Printf("Admin endpoint failed: %v", err)
+		}
+	}()
+	context.AfterFunc(ctx, func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	})
+}
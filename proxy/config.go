@@ -0,0 +1,102 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// backendFile 后端配置文件的格式
+type backendFile struct {
+	Backends []backendEntry `json:"backends" yaml:"backends"`
+}
+
+// backendEntry 单个后端，凭据和数据库名为空时使用命令行参数
+type backendEntry struct {
+	Name     string `json:"name" yaml:"name"`
+	Host     string `json:"host" yaml:"host"`
+	Port     int    `json:"port" yaml:"port"`
+	Priority int    `json:"priority" yaml:"priority"`
+	Weight   int    `json:"weight" yaml:"weight"`
+	Username string `json:"username" yaml:"username"`
+	Password string `json:"password" yaml:"password"`
+	Database string `json:"database" yaml:"database"`
+}
+
+// loadBackendFile 从JSON或YAML文件（按扩展名.yaml/.yml区分）读取后端列表
+func loadBackendFile(path string) ([]backendEntry, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read proxy config: %w", err)
+	}
+
+	var cfg backendFile
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(b, &cfg)
+	default:
+		err = json.Unmarshal(b, &cfg)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse proxy config %s: %w", path, err)
+	}
+	if len(cfg.Backends) == 0 {
+		return nil, fmt.Errorf("proxy config %s: no backends", path)
+	}
+	for i, e := range cfg.Backends {
+		if e.Host == "" {
+			return nil, fmt.Errorf("proxy config %s: backend %d has no host", path, i+1)
+		}
+	}
+	return cfg.Backends, nil
+}
+
+// flagBackends 由并列的命令行参数组成后端列表：--route-name、--route-priority、--route-weight、
+// --db-port的个数须与--db-host一致，除--route-name外也可以只给一个值用于所有后端，
+// 省略--route-name时以主机名命名
+func (o *DBProxyOptions) flagBackends() ([]backendEntry, error) {
+	if len(o.DbHost) == 0 {
+		return nil, fmt.Errorf("no backends: give --db-host (one per backend) or --config")
+	}
+	n := len(o.DbHost)
+	if len(o.RouteName) != 0 && len(o.RouteName) != n {
+		return nil, fmt.Errorf("--route-name given %d times but --db-host %d times", len(o.RouteName), n)
+	}
+	if len(o.RoutePriority) > 1 && len(o.RoutePriority) != n {
+		return nil, fmt.Errorf("--route-priority given %d times but --db-host %d times", len(o.RoutePriority), n)
+	}
+	if len(o.RouteWeight) > 1 && len(o.RouteWeight) != n {
+		return nil, fmt.Errorf("--route-weight given %d times but --db-host %d times", len(o.RouteWeight), n)
+	}
+	if len(o.DbPort) > 1 && len(o.DbPort) != n {
+		return nil, fmt.Errorf("--db-port given %d times but --db-host %d times", len(o.DbPort), n)
+	}
+
+	entries := make([]backendEntry, n)
+	for i, host := range o.DbHost {
+		e := backendEntry{Name: host, Host: host}
+		if len(o.RouteName) > 0 {
+			e.Name = o.RouteName[i]
+		}
+		e.Priority = pick(o.RoutePriority, i)
+		e.Weight = pick(o.RouteWeight, i)
+		e.Port = pick(o.DbPort, i)
+		entries[i] = e
+	}
+	return entries, nil
+}
+
+// pick 返回第i个值，只有一个值时所有后端共用，没有值时为0
+func pick(values []int, i int) int {
+	switch len(values) {
+	case 0:
+		return 0
+	case 1:
+		return values[0]
+	}
+	return values[i]
+}
@@ -0,0 +1,56 @@
+package proxy
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFlagBackends(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    DBProxyOptions
+		want    []backendEntry
+		wantErr string
+	}{
+		{
+			name: "per-host values",
+			opts: DBProxyOptions{DbHost: []string{"a", "b"}, RouteName: []string{"primary", "standby"},
+				RoutePriority: []int{1, 2}, RouteWeight: []int{3, 0}, DbPort: []int{1521, 1522}},
+			want: []backendEntry{
+				{Name: "primary", Host: "a", Port: 1521, Priority: 1, Weight: 3},
+				{Name: "standby", Host: "b", Port: 1522, Priority: 2},
+			},
+		},
+		{
+			name: "single values shared",
+			opts: DBProxyOptions{DbHost: []string{"a", "b"}, RoutePriority: []int{1}, RouteWeight: []int{2}, DbPort: []int{5432}},
+			want: []backendEntry{
+				{Name: "a", Host: "a", Port: 5432, Priority: 1, Weight: 2},
+				{Name: "b", Host: "b", Port: 5432, Priority: 1, Weight: 2},
+			},
+		},
+		{name: "no hosts", opts: DBProxyOptions{}, wantErr: "no backends"},
+		{name: "route-name mismatch", opts: DBProxyOptions{DbHost: []string{"a", "b"}, RouteName: []string{"x"}}, wantErr: "--route-name given 1 times"},
+		{name: "route-priority mismatch", opts: DBProxyOptions{DbHost: []string{"a", "b"}, RoutePriority: []int{1, 2, 3}}, wantErr: "--route-priority given 3 times"},
+		{name: "route-weight mismatch", opts: DBProxyOptions{DbHost: []string{"a", "b", "c"}, RouteWeight: []int{1, 2}}, wantErr: "--route-weight given 2 times"},
+		{name: "db-port mismatch", opts: DBProxyOptions{DbHost: []string{"a", "b", "c"}, DbPort: []int{1, 2}}, wantErr: "--db-port given 2 times"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.opts.flagBackends()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("flagBackends() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("flagBackends() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
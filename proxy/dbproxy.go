@@ -1,10 +1,15 @@
 package proxy
 
 import (
+	"context"
+	"crypto/tls"
 	"fmt"
 	"github.com/yusiwen/myUtilities/core/proxy"
 	"github.com/yusiwen/myUtilities/core/proxy/db"
+	"os"
+	"os/signal"
 	"sort"
+	"syscall"
 	"time"
 )
 
@@ -13,51 +18,111 @@ func (o *DBProxyOptions) Run() error {
 	if err != nil {
 		return err
 	}
-	err = p.Start()
-	if err != nil {
-		return err
-	}
 	defer p.Close()
-	return nil
+
+	// Ctrl-C/SIGTERM后停止接受新连接，等待已有会话结束
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if o.AdminPort > 0 {
+		serveAdmin(ctx, getListenAddr(o.Host, o.AdminPort), p)
+	}
+	return p.Start(ctx)
 }
 
-func (o *DBProxyOptions) parseOptions() (*db.OracleProxy, error) {
-	backends, err := o.getBackends()
+func (o *DBProxyOptions) parseOptions() (*db.DBProxy, error) {
+	dialect, err := db.DialectFor(o.Mode)
+	if err != nil {
+		return nil, err
+	}
+	backends, err := o.getBackends(dialect)
 	if err != nil {
 		return nil, err
 	}
-	p := &db.OracleProxy{
+	p := &db.DBProxy{
 		DefaultProxy: proxy.DefaultProxy{
-			ListenAddr: getListenAddr(o.Host, o.Port),
+			ListenAddr:         getListenAddr(o.Host, o.Port),
+			Balance:            o.Balance,
+			Failback:           o.Failback,
+			FailbackIdle:       o.FailbackIdle,
+			ShutdownTimeout:    o.ShutdownTimeout,
+			MaxConnsPerBackend: o.MaxConnsPerBackend,
+			DialTimeout:        o.DialTimeout,
+			IdleTimeout:        o.IdleTimeout,
+			BackendTLS:         o.BackendTls,
+			BackendTLSInsecure: o.BackendTlsInsecure,
 		},
 		Backends: backends,
+		Dialect:  dialect,
 	}
+	if o.ListenTlsCert != "" || o.ListenTlsKey != "" {
+		if o.ListenTlsCert == "" || o.ListenTlsKey == "" {
+			return nil, fmt.Errorf("--listen-tls-cert and --listen-tls-key must be given together")
+		}
+		cert, err := tls.LoadX509KeyPair(o.ListenTlsCert, o.ListenTlsKey)
+		if err != nil {
+			return nil, fmt.Errorf("load listen certificate: %w", err)
+		}
+		p.ListenTLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+	}
+	// 未指定健康检查语句时使用该数据库类型的默认语句
 	p.HealthCheck.Query = o.DbTestQuery
 	p.HealthCheck.Expected = o.DbTestExpected
+	if p.HealthCheck.Query == "" {
+		p.HealthCheck.Query = dialect.DefaultQuery
+		p.HealthCheck.Expected = dialect.DefaultExpected
+	}
 	p.HealthCheck.Timeout = time.Duration(o.DbTestTimeout) * time.Second
 	p.HealthCheck.Interval = time.Duration(o.DbTestInterval) * time.Second
 
 	return p, nil
 }
 
-func (o *DBProxyOptions) getBackends() ([]*db.OracleBackendStatus, error) {
-	var backends []*db.OracleBackendStatus
-	for i, host := range o.DbHost {
-		backends = append(backends, &db.OracleBackendStatus{
-			Config: db.OracleBackendConfig{
+// getBackends 从--config文件或命令行参数读取后端，按优先级排序，优先级相同时保持配置顺序
+func (o *DBProxyOptions) getBackends(dialect *db.Dialect) ([]*db.DBBackendStatus, error) {
+	var entries []backendEntry
+	var err error
+	if o.Config != "" {
+		entries, err = loadBackendFile(o.Config)
+	} else {
+		entries, err = o.flagBackends()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var backends []*db.DBBackendStatus
+	for _, e := range entries {
+		if e.Name == "" {
+			e.Name = e.Host
+		}
+		if e.Port == 0 {
+			e.Port = dialect.DefaultPort
+		}
+		if e.Username == "" {
+			e.Username = o.DbUsername
+		}
+		if e.Password == "" {
+			e.Password = o.DbPassword
+		}
+		if e.Database == "" {
+			e.Database = o.DbName
+		}
+		backends = append(backends, &db.DBBackendStatus{
+			Config: db.DBBackendConfig{
 				BackendConfig: proxy.BackendConfig{
-					Name:     o.RouteName[i],
-					Host:     host,
-					Port:     o.DbPort[i],
-					Priority: o.RoutePriority[i],
+					Name:     e.Name,
+					Host:     e.Host,
+					Port:     e.Port,
+					Priority: e.Priority,
+					Weight:   e.Weight,
 				},
-				Username:    o.DbUsername,
-				Password:    o.DbPassword,
-				ServiceName: o.DbName,
+				Username: e.Username,
+				Password: e.Password,
+				Database: e.Database,
 			},
 		})
 	}
-	sort.Slice(backends, func(i, j int) bool {
+	sort.SliceStable(backends, func(i, j int) bool {
 		return backends[i].Config.Priority < backends[j].Config.Priority
 	})
 	return backends, nil
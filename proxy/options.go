@@ -1,22 +1,45 @@
 package proxy
 
+import "time"
+
 type DBProxyOptions struct {
-	Host           string   `help:"Host to listen on." default:"localhost"`
-	Port           int      `help:"Port to listen on." default:"1521"`
-	Mode           string   `help:"Mode of database" default:"oracle"`
-	RouteName      []string `help:"Name of route" default:""`
-	RoutePriority  []int    `help:"Priority of route" default:"0"`
-	DbHost         []string `help:"Host of database" default:""`
-	DbPort         []int    `help:"Port of database" default:"1521"`
-	DbName         string   `help:"Name of database" default:""`
-	DbUsername     string   `help:"User name to connect to database" default:""`
-	DbPassword     string   `help:"Password to connect to database" default:""`
-	DbTestQuery    string   `help:"SQL query statement to test connection" default:"SELECT '1' FROM DUAL"`
-	DbTestExpected string   `help:"Expected result of SQL query statement to test connection" default:"1"`
-	DbTestTimeout  int      `help:"Timeout in seconds for health check." default:"5"`
-	DbTestInterval int      `help:"Interval in seconds for health check." default:"10"`
+	Host               string        `help:"Host to listen on." default:"localhost"`
+	Port               int           `help:"Port to listen on." default:"1521"`
+	AdminPort          int           `help:"Port of the admin endpoint serving /status and /status.json on --host, e.g. 9521 (0 = disabled)." default:"0"`
+	Mode               string        `help:"Mode of database: oracle, mysql or postgres" enum:"oracle,mysql,postgres" default:"oracle"`
+	Balance            string        `help:"How to pick a backend for each new connection: 'priority' (highest priority available), 'round-robin' or 'least-conn'." enum:"priority,round-robin,least-conn" default:"priority"`
+	Failback           bool          `help:"When a higher-priority backend recovers, close idle connections to lower-priority backends so clients reconnect to it (priority balancing only)."`
+	FailbackIdle       time.Duration `help:"How long a connection must be idle before --failback closes it." default:"30s"`
+	ShutdownTimeout    time.Duration `help:"On Ctrl-C/SIGTERM, how long to wait for active connections to finish before closing them." default:"30s"`
+	DialTimeout        time.Duration `help:"Timeout for connecting a client to its backend." default:"3s"`
+	IdleTimeout        time.Duration `help:"Close proxied connections with no traffic in either direction for this long (0 = never)." default:"0s"`
+	ListenTlsCert      string        `help:"PEM certificate for accepting TLS client connections (requires --listen-tls-key)."`
+	ListenTlsKey       string        `help:"PEM private key for --listen-tls-cert."`
+	BackendTls         bool          `help:"Connect to the backends over TLS, for forwarding and health checks."`
+	BackendTlsInsecure bool          `help:"Skip verification of backend certificates (self-signed development certs)."`
+	MaxConnsPerBackend int           `help:"Maximum client connections per backend (0 = unlimited); when the chosen backend is full the next healthy one is used, and clients are rejected when all are full."`
+	Config             string        `help:"JSON or YAML file listing the backends (name, host, port, priority, username, password, database) instead of the --route-*/--db-* flags." type:"existingfile"`
+	RouteName          []string      `help:"Name of route" default:""`
+	RoutePriority      []int         `help:"Priority of route (one per --db-host, or a single value for all)"`
+	RouteWeight        []int         `help:"Traffic weight of route among routes of the same priority (one per --db-host); 0 = only used when the weighted routes of that priority are down"`
+	DbHost             []string      `help:"Host of database" default:""`
+	DbPort             []int         `help:"Port of database (one per --db-host, or a single value for all; defaults to the port of --mode)"`
+	DbName             string        `help:"Name of database" default:""`
+	DbUsername         string        `help:"User name to connect to database" default:""`
+	DbPassword         string        `help:"Password to connect to database" default:""`
+	DbTestQuery        string        `help:"SQL query statement to test connection (default: SELECT '1' FROM DUAL for oracle, SELECT 1 otherwise)" default:""`
+	DbTestExpected     string        `help:"Expected result of SQL query statement to test connection" default:"1"`
+	DbTestTimeout      int           `help:"Timeout in seconds for health check." default:"5"`
+	DbTestInterval     int           `help:"Interval in seconds for health check." default:"10"`
+}
+
+type StatusOptions struct {
+	Addr    string        `help:"Admin address of the running proxy." default:"localhost:9521"`
+	JSON    bool          `name:"json" help:"Print the report as JSON."`
+	Timeout time.Duration `help:"Request timeout." default:"5s"`
 }
 
 type Options struct {
 	DBProxy DBProxyOptions `cmd:"" name:"db" help:"Start a database proxy."`
+	Status  StatusOptions  `cmd:"" name:"status" help:"Print the status report of a running database proxy."`
 }
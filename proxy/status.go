@@ -0,0 +1,58 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/yusiwen/myUtilities/core/proxy"
+)
+
+// Run 查询运行中代理的管理接口并输出状态报告
+func (o *StatusOptions) Run() error {
+	base := o.Addr
+	if !strings.HasPrefix(base, "http://") && !strings.HasPrefix(base, "https://") {
+		base = "http://" + base
+	}
+	path := "/status"
+	if o.JSON {
+		path = "/status.json"
+	}
+
+	client := &http.Client{Timeout: o.Timeout}
+	resp, err := client.Get(strings.TrimSuffix(base, "/") + path)
+	if err != nil {
+		return fmt.Errorf("query proxy status: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read proxy status: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("proxy status: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	if !o.JSON {
+		fmt.Print(string(body))
+		return nil
+	}
+
+	// 校验并格式化JSON输出
+	var report proxy.StatusReport
+	if err := json.Unmarshal(body, &report); err != nil {
+		return fmt.Errorf("decode proxy status: %w", err)
+	}
+	var out bytes.Buffer
+	if err := json.Indent(&out, body, "", "  "); err != nil {
+		return err
+	}
+	out.WriteByte('\n')
+	_, err = out.WriteTo(os.Stdout)
+	return err
+}
@@ -0,0 +1,86 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yusiwen/myUtilities/core/runner"
+	"gopkg.in/yaml.v3"
+)
+
+// commandFile 命令文件的格式
+type commandFile struct {
+	Commands []commandEntry `json:"commands" yaml:"commands"`
+}
+
+// commandEntry 单个命令，名称为空时以命令行命名，超时为空时使用--timeout，
+// 工作目录为空时使用当前目录，env中的变量追加到继承的环境变量上，
+// shell为空时使用--shell/--no-shell，为none时不经过shell直接执行
+type commandEntry struct {
+	Name    string            `json:"name" yaml:"name"`
+	Command string            `json:"command" yaml:"command"`
+	Timeout string            `json:"timeout" yaml:"timeout"` // 如 30s、5m
+	Dir     string            `json:"dir" yaml:"dir"`
+	Env     map[string]string `json:"env" yaml:"env"`
+	Shell   string            `json:"shell" yaml:"shell"`
+}
+
+// loadCommandFile 从JSON或YAML文件（按扩展名.yaml/.yml区分）读取命令列表
+func loadCommandFile(path string) ([]runner.Command, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read command file: %w", err)
+	}
+
+	var cfg commandFile
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(b, &cfg)
+	default:
+		err = json.Unmarshal(b, &cfg)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse command file %s: %w", path, err)
+	}
+
+	commands := make([]runner.Command, len(cfg.Commands))
+	for i, e := range cfg.Commands {
+		if e.Command == "" {
+			return nil, fmt.Errorf("command file %s: command %d is empty", path, i+1)
+		}
+		c := runner.Command{Name: e.Name, CmdLine: e.Command, Dir: e.Dir, Env: envList(e.Env)}
+		if c.Name == "" {
+			c.Name = e.Command
+		}
+		if e.Shell == "none" {
+			c.NoShell = true
+		} else {
+			c.Shell = e.Shell
+		}
+		if e.Timeout != "" {
+			if c.Timeout, err = time.ParseDuration(e.Timeout); err != nil {
+				return nil, fmt.Errorf("command file %s: command %d: invalid timeout: %w", path, i+1, err)
+			}
+		}
+		commands[i] = c
+	}
+	return commands, nil
+}
+
+// envList 将环境变量转换为KEY=VALUE列表，按名称排序保证顺序稳定
+func envList(env map[string]string) []string {
+	if len(env) == 0 {
+		return nil
+	}
+	list := make([]string, 0, len(env))
+	for k, v := range env {
+		list = append(list, k+"="+v)
+	}
+	sort.Strings(list)
+	return list
+}
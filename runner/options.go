@@ -1,9 +1,13 @@
 package runner
 
-import (
-	"github.com/yusiwen/myUtilities/core/runner"
-)
+import "time"
 
 type CommandRunnerOptions struct {
-	Commands []runner.Command `embed:"" prefix:"runner." help:"Commands to run."`
+	Commands  []string      `help:"Command line to run, repeat the flag for more commands." sep:"none"`
+	File      string        `help:"YAML or JSON file listing the commands to run, before any --commands." type:"existingfile"`
+	Parallel  int           `help:"Maximum number of commands running at the same time." default:"1"`
+	KeepGoing bool          `help:"Keep running the remaining commands after a failure and report all results at the end, instead of canceling them at the first failure."`
+	Timeout   time.Duration `help:"Kill a command (and its child processes) that runs longer than this, 0 for no limit. Commands in --file may set their own timeout." default:"0"`
+	Shell     string        `help:"Shell running the commands, e.g. sh, zsh, \"bash -lc\", powershell. Defaults to bash, or cmd on Windows. Commands in --file may set their own shell." xor:"shell"`
+	NoShell   bool          `help:"Run commands directly without a shell. The command line is split on spaces honoring quotes; pipes, redirections and variables are not available." xor:"shell"`
 }
@@ -5,6 +5,21 @@ import (
 )
 
 func (o *CommandRunnerOptions) Run() error {
-	r := runner.NewCommandRunner(o.Commands)
+	var commands []runner.Command
+	if o.File != "" {
+		var err error
+		if commands, err = loadCommandFile(o.File); err != nil {
+			return err
+		}
+	}
+	for _, c := range o.Commands {
+		commands = append(commands, runner.Command{Name: c, CmdLine: c})
+	}
+	r := runner.NewCommandRunner(commands)
+	r.Parallel = o.Parallel
+	r.KeepGoing = o.KeepGoing
+	r.Timeout = o.Timeout
+	r.Shell = o.Shell
+	r.NoShell = o.NoShell
 	return r.Run()
 }
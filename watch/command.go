@@ -21,6 +21,22 @@ func (o *FileOptions) Run() error {
 	}
 
 	fw := watcher.NewFileWatcher(absDir, o.Interval)
+	if o.Once {
+		if o.Snapshot == "" {
+			return fmt.Errorf("--snapshot is required with --once")
+		}
+		events, err := fw.ScanOnce(o.Snapshot)
+		if err != nil {
+			return fmt.Errorf("scan: %w", err)
+		}
+		for _, ev := range events {
+			if o.matchFilter(ev) {
+				printEvent(ev)
+			}
+		}
+		return nil
+	}
+
 	eventCh, err := fw.Watch(ctx)
 	if err != nil {
 		return fmt.Errorf("start watching: %w", err)
@@ -41,8 +57,7 @@ func (o *FileOptions) Run() error {
 			if !o.matchFilter(ev) {
 				continue
 			}
-			fmt.Printf("[%s] %-8s %v\n",
-				ev.Timestamp.Format("2006-01-02 15:04:05"), ev.Type, ev.Object)
+			printEvent(ev)
 
 		case <-ctx.Done():
 			fw.Stop()
@@ -52,6 +67,11 @@ func (o *FileOptions) Run() error {
 	}
 }
 
+func printEvent(ev watcher.Event) {
+	fmt.Printf("[%s] %-8s %v\n",
+		ev.Timestamp.Format("2006-01-02 15:04:05"), ev.Type, ev.Object)
+}
+
 func (o *FileOptions) matchFilter(ev watcher.Event) bool {
 	path, ok := ev.Object.(string)
 	if !ok {
@@ -99,6 +119,20 @@ func (o *GitOptions) Run() error {
 
 	auth := resolveGitAuth()
 	gw := watcher.NewGitWatcher(absDir, o.Remote, o.Branch, auth, o.Interval)
+	if o.Once {
+		if o.Snapshot == "" {
+			return fmt.Errorf("--snapshot is required with --once")
+		}
+		events, err := gw.ScanOnce(o.Snapshot)
+		if err != nil {
+			return fmt.Errorf("check: %w", err)
+		}
+		for _, ev := range events {
+			printEvent(ev)
+		}
+		return nil
+	}
+
 	eventCh, err := gw.Watch(ctx)
 	if err != nil {
 		return fmt.Errorf("start watching: %w", err)
@@ -121,8 +155,7 @@ func (o *GitOptions) Run() error {
 				fmt.Fprintf(os.Stderr, "error: %v\n", ev.Object)
 				continue
 			}
-			fmt.Printf("[%s] %-8s %v\n",
-				ev.Timestamp.Format("2006-01-02 15:04:05"), ev.Type, ev.Object)
+			printEvent(ev)
 
 		case <-ctx.Done():
 			gw.Stop()
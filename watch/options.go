@@ -14,6 +14,8 @@ type FileOptions struct {
 	Interval time.Duration `help:"Polling interval." default:"5s"`
 	Include  []string      `name:"include" help:"Glob pattern to include (repeatable)."`
 	Exclude  []string      `name:"exclude" help:"Glob pattern to exclude (repeatable)."`
+	Once     bool          `help:"Scan once, print changes since the last --snapshot and exit."`
+	Snapshot string        `help:"Snapshot file used by --once." type:"path"`
 }
 
 type GitOptions struct {
@@ -21,4 +23,6 @@ type GitOptions struct {
 	Remote   string        `help:"Remote name." default:"origin"`
 	Branch   string        `help:"Branch to track." default:""`
 	Interval time.Duration `help:"Polling interval." default:"60s"`
+	Once     bool          `help:"Check once, pull if the remote moved since the last --snapshot and exit."`
+	Snapshot string        `help:"Snapshot file used by --once." type:"path"`
 }